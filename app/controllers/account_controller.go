@@ -0,0 +1,437 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/mailer"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangePassword method for change password of the current user.
+// @Description Change password with proof of the current one. Other sessions of the user are terminated.
+// @Summary change own password
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body models.ChangePasswordInput true "current and new password"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/me/password [post]
+func ChangePassword(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new ChangePasswordInput struct
+	input := &models.ChangePasswordInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a ChangePasswordInput model.
+	validate := utils.NewValidator()
+
+	// Validate password fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Checking, if the current password is known to the caller.
+	if ok, err := checkCurrentPassword(c, db, user, input.CurrentPassword); !ok {
+		return err
+	}
+
+	// Check new password by policy.
+	if err := passwordpolicy.Default().Check(input.NewPassword, user.Email, user.Name); err != nil {
+		// Return, if password is too weak.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Save hash of the new password.
+	if err := rehashPassword(c.UserContext(), db, user.ID, input.NewPassword); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Invalidate reset links sent to the user, they are not needed anymore.
+	if err := db.UseUserPasswordResetTokens(c.UserContext(), user.ID); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Sign out other devices, they may be used by someone, who knows the old password.
+	if err := terminateOtherSessions(c.UserContext(), db, user.ID, tokenMetadata.SessionID, tokenMetadata.ID); err != nil {
+		// Return status 500 and revocation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ChangeEmail method for request change of email of the current user.
+// @Description Send confirmation link to the new email. The email is changed only after confirmation.
+// @Summary change own email
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body models.ChangeEmailInput true "current password and new email"
+// @Success 202 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/me/email [post]
+func ChangeEmail(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new ChangeEmailInput struct
+	input := &models.ChangeEmailInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a ChangeEmailInput model.
+	validate := utils.NewValidator()
+
+	// Validate email fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Checking, if the current password is known to the caller.
+	if ok, err := checkCurrentPassword(c, db, user, input.CurrentPassword); !ok {
+		return err
+	}
+
+	// Checking, if email is the same.
+	if strings.EqualFold(input.Email, user.Email) {
+		// Return status 409 and conflict error.
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": true,
+			"msg":   "email is the same as the current one",
+		})
+	}
+
+	// Checking, if the previous email was sent recently.
+	if latest, err := db.GetLatestEmailVerificationToken(c.UserContext(), user.ID); err == nil {
+		if wait := time.Until(latest.CreatedAt.Add(configs.Default().EmailVerification.ResendInterval)); wait > 0 {
+			// Return status 429 and the time to wait.
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())+1))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": true,
+				"msg":   "confirmation email was sent recently, try again later",
+			})
+		}
+	}
+
+	// Send confirmation link to the new email. Taken emails are checked on confirmation,
+	// so the response does not tell, whether the email is registered.
+	if err := sendEmailChange(c.UserContext(), db, user, input.Email); err != nil {
+		// Return status 500 and mail error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 202 accepted.
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ConfirmEmailChange method for change email of the user with the token from confirmation email.
+// @Description Confirm the new email address. Every token can be used only once.
+// @Summary confirm email change
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body models.ConfirmEmailChangeInput true "confirmation token"
+// @Success 204 {string} status "ok"
+// @Router /v1/email/confirm [post]
+func ConfirmEmailChange(c *fiber.Ctx) error {
+	// Create new ConfirmEmailChangeInput struct
+	input := &models.ConfirmEmailChangeInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a ConfirmEmailChangeInput model.
+	validate := utils.NewValidator()
+
+	// Validate confirmation fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Verify signature, expiration and purpose of the token.
+	tokenMetadata, err := utils.ParsePurposeToken(input.Token, utils.EmailChangePurpose)
+	if err != nil {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "confirmation token is invalid or expired",
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get issued token, it keeps the new email.
+	foundedToken, err := db.GetEmailVerificationToken(c.UserContext(), tokenMetadata.ID)
+	if err != nil || foundedToken.UserID != tokenMetadata.UserId {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "confirmation token is invalid or expired",
+		})
+	}
+
+	// Mark token as used, so it can not be used again.
+	used, err := db.UseEmailVerificationToken(c.UserContext(), foundedToken.ID)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !used {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "confirmation token was already used",
+		})
+	}
+
+	// Replace email of the user, it is verified by this confirmation.
+	if err := db.ChangeUserEmail(c.UserContext(), foundedToken.UserID, foundedToken.Email); err != nil {
+		// Return status 409, if the email was taken after the token was issued.
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": true,
+				"msg":   "email is already used by another user",
+			})
+		}
+
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Reset links were sent to the old email, they must not work anymore.
+	if err := db.UseUserPasswordResetTokens(c.UserContext(), foundedToken.UserID); err != nil {
+		logrus.WithField("user_id", foundedToken.UserID).Error(err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// checkCurrentPassword func for verify the current password of the user before account changes.
+// Failures are throttled like sign in attempts. It responds itself, if the password is not accepted.
+func checkCurrentPassword(c *fiber.Ctx, db *database.Queries, user models.User, password string) (bool, error) {
+	// Checking, if the account or the client IP is blocked.
+	attempt, until, locked, err := startSignInAttempt(c, db, user.Email)
+	if err != nil {
+		// Return status 500 and database error.
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if attempt == nil {
+		// Return status 423 or 429 with the time to wait.
+		return false, signInBlocked(c, until, locked)
+	}
+	defer attempt.finish()
+
+	// Compare given password with the stored hash.
+	match, _, err := utils.VerifyPassword(user.Password, password)
+	if err != nil {
+		// Return status 500 and password verification error.
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !match {
+		// Count failure, so next attempts are slowed down.
+		if err := attempt.fail(c.UserContext()); err != nil {
+			// Return status 500 and database error.
+			return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+
+		// Return status 403 and password error.
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   "current password is wrong",
+		})
+	}
+
+	return true, nil
+}
+
+// sendEmailChange func for issue a new confirmation token and send it to the new email.
+// The current email gets a notice, so the owner knows about the request.
+func sendEmailChange(ctx context.Context, db *database.Queries, user models.User, email string) error {
+	config := configs.Default().EmailChange
+
+	// Generate a new signed confirmation token.
+	token, jti, err := utils.GenerateNewPurposeToken(utils.EmailChangePurpose, user.ID, nil, config.TTL)
+	if err != nil {
+		return err
+	}
+
+	// Save token ID with the new email, so it can be used only once.
+	now := time.Now()
+	if err := db.CreateEmailVerificationToken(ctx, &models.EmailVerificationToken{
+		ID:        jti,
+		UserID:    user.ID,
+		Email:     email,
+		ExpiresAt: now.Add(config.TTL),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	if err := mailer.Default().Send(mailer.Message{
+		To:      email,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nconfirm your new email address by following the link:\n%s%s\n\nThe link expires in %d hours.\n",
+			user.Name, config.URL, token, int(config.TTL.Hours()),
+		),
+	}); err != nil {
+		return err
+	}
+
+	// Notice is informational, failure to send it does not cancel the change.
+	if err := mailer.Default().Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your email is being changed",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nthe email of your account is being changed to %s.\nIf it was not you, reset your password.\n",
+			user.Name, email,
+		),
+	}); err != nil {
+		logrus.WithField("user_id", user.ID).Error(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"time"
+)
+
+// CreateAPIKey func for creates a new personal API key.
+// @Description Create a new API key with the given scopes. The key is shown only once, send it in "X-API-Key" header.
+// @Summary create a new API key
+// @Tags APIKey
+// @Accept json
+// @Produce json
+// @Param input body models.APIKeyCreateInput true "API key"
+// @Success 201 {object} models.APIKey
+// @Security ApiKeyAuth
+// @Router /v1/api-key [post]
+func CreateAPIKey(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new APIKeyCreateInput struct
+	input := &models.APIKeyCreateInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a APIKeyCreateInput model.
+	validate := utils.NewValidator()
+
+	// Validate API key fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Checking, if the key is not already expired.
+	if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+		// Return status 400 and expiration error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "expiration time of API key must be in the future",
+		})
+	}
+
+	// Key can not have scopes, which the role of the user does not have.
+	for _, scope := range input.Scopes {
+		if !rbac.Can(tokenMetadata.Role, rbac.Permission(scope)) {
+			// Return status 403 and scope error.
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "forbidden, scope " + scope + " is not allowed",
+			})
+		}
+	}
+
+	// Generate a new API key.
+	key, prefix, hash, err := utils.GenerateNewAPIKey()
+	if err != nil {
+		// Return status 500 and key generation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Set initialized default data for API key:
+	apiKey := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    tokenMetadata.UserId,
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	// Create a new API key.
+	if err := db.CreateAPIKey(c.UserContext(), apiKey); err != nil {
+		// Return status 500 and create API key process error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 201 created, the key is shown only once.
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"msg":     nil,
+		"key":     key,
+		"api_key": apiKey,
+	})
+}
+
+// GetAPIKeys func gets all API keys of the current user.
+// @Description Get all API keys of the current user, including revoked ones.
+// @Summary get all API keys
+// @Tags APIKey
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Security ApiKeyAuth
+// @Router /v1/api-keys [get]
+func GetAPIKeys(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get all API keys of the user.
+	keys, err := db.GetAPIKeys(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"msg":      nil,
+		"count":    len(keys),
+		"api_keys": keys,
+	})
+}
+
+// RevokeAPIKey func for revokes API key of the current user.
+// @Description Revoke API key by given ID, it can not be used anymore.
+// @Summary revoke API key
+// @Tags APIKey
+// @Accept json
+// @Produce json
+// @Param input body models.APIKeyRevokeInput true "API key ID"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/api-key [delete]
+func RevokeAPIKey(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new APIKeyRevokeInput struct
+	input := &models.APIKeyRevokeInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a APIKeyRevokeInput model.
+	validate := utils.NewValidator()
+
+	// Validate API key ID field.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke API key, only own keys can be revoked.
+	revoked, err := db.RevokeAPIKey(c.UserContext(), input.ID, tokenMetadata.UserId)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !revoked {
+		// Return status 404 and API key not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "API key with this ID not found",
+		})
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
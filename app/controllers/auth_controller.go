@@ -1,17 +1,27 @@
 package controllers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/dto"
 	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
+	"github.com/popeskul/houser/pkg/rbac"
 	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
 	"github.com/popeskul/houser/platform/database"
+	"github.com/popeskul/houser/platform/revocation"
+	"github.com/sirupsen/logrus"
 	"time"
 )
 
 // SignIn method for login to the system.
-// @Description SignIn to the system with the token.
-// @Summary login and creates a new access token
+// @Description SignIn to the system with the token. Users with two-factor authentication get "mfa_token" instead, see /v1/sign-in/mfa.
+// @Description Failed attempts slow down further ones per account and per IP (429), too many of them lock the account (423).
+// @Summary login and creates a new access and refresh tokens
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -31,8 +41,20 @@ func SignIn(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Create a new validator for a SignInInput model.
+	validate := utils.NewValidator()
+
+	// Validate sign in fields, too long passwords are not hashed at all.
+	if err := validate.Struct(parsedUser); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -41,32 +63,86 @@ func SignIn(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get user by ID.
-	user, err := db.Login(parsedUser.Email, parsedUser.Password)
+	// Get user by email, unknown emails are throttled and logged the same way.
+	user, err := db.Login(c.UserContext(), parsedUser.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	var userID *uuid.UUID
+	if err == nil {
+		userID = &user.ID
+	}
+
+	// Checking, if the account or the client IP is blocked after failed attempts.
+	attempt, until, locked, err := startSignInAttempt(c, db, parsedUser.Email)
 	if err != nil {
-		// Return, if user not found.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": true,
-			"msg":   "user with the given email and password is not found",
-			"user":  nil,
+			"msg":   err.Error(),
 		})
 	}
+	if attempt == nil {
+		outcome := models.SignInThrottled
+		if locked {
+			outcome = models.SignInLocked
+		}
+		logSignInAttempt(c, db, parsedUser.Email, userID, outcome)
+
+		// Return status 423 or 429 with the time to wait.
+		return signInBlocked(c, until, locked)
+	}
+	defer attempt.finish()
 
-	// Generate a new Access token.
-	token, err := utils.GenerateNewAccessToken(user)
+	// Compare given password with the stored hash. Unknown emails are compared
+	// with hash of nobody, so they take as long as registered ones.
+	match := false
+	rehash := false
+	if userID != nil {
+		match, rehash, err = utils.VerifyPassword(user.Password, parsedUser.Password)
+	} else {
+		err = utils.VerifyDummyPassword(parsedUser.Password)
+	}
 	if err != nil {
-		// Return status 500 and token generation error.
+		// Return status 500 and password verification error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": true,
 			"msg":   err.Error(),
 		})
 	}
+	if !match {
+		// Count failure, so next attempts are slowed down.
+		if err := attempt.fail(c.UserContext()); err != nil {
+			// Return status 500 and database error.
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+		logSignInAttempt(c, db, parsedUser.Email, userID, models.SignInFailed)
 
-	return c.JSON(fiber.Map{
-		"error":        false,
-		"msg":          nil,
-		"access_token": token,
-	})
+		// Return, if user not found or password is wrong.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with the given email and password is not found",
+			"user":  nil,
+		})
+	}
+
+	// Upgrade legacy plaintext or outdated password hash.
+	if rehash {
+		if err := rehashPassword(c.UserContext(), db, user.ID, parsedUser.Password); err != nil {
+			// Sign in is still allowed, the hash will be upgraded next time.
+			logrus.WithField("user_id", user.ID).Error(err)
+		}
+	}
+
+	// Ask for the second factor or grant tokens.
+	return completeSignIn(c, db, user)
 }
 
 // SignUp method for sign up
@@ -79,11 +155,11 @@ func SignIn(c *fiber.Ctx) error {
 // @Success 200 {string} status "ok"
 // @Router /v1/sign-up [post]
 func SignUp(c *fiber.Ctx) error {
-	// Create new User struct
-	user := &models.User{}
+	// Create new SignUpInput struct
+	input := &models.SignUpInput{}
 
 	// Check, if received JSON data is valid.
-	if err := c.BodyParser(user); err != nil {
+	if err := c.BodyParser(input); err != nil {
 		// Return status 400 and error message
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": true,
@@ -91,8 +167,11 @@ func SignUp(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Create new User struct from input.
+	user := &models.User{Name: input.Name, Email: input.Email, Password: input.Password}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -106,6 +185,8 @@ func SignUp(c *fiber.Ctx) error {
 
 	// Set initialized default data for user:
 	user.ID = uuid.New()
+	user.Role = string(rbac.DefaultRole)
+	user.VerifiedAt = nil
 	user.CreatedAt = time.Now()
 
 	// Validate user fields.
@@ -117,8 +198,27 @@ func SignUp(c *fiber.Ctx) error {
 		})
 	}
 
+	// Check password by policy.
+	if err := passwordpolicy.Default().Check(user.Password, user.Email, user.Name); err != nil {
+		// Return, if password is too weak.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Replace given password with its hash.
+	user.Password, err = utils.HashPassword(user.Password)
+	if err != nil {
+		// Return status 500 and password hashing error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
 	// Get user by ID.
-	_, err = db.RegisterUser(user)
+	_, err = db.RegisterUser(c.UserContext(), user)
 	if err != nil {
 		// Return, if user not found.
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -128,10 +228,251 @@ func SignUp(c *fiber.Ctx) error {
 		})
 	}
 
+	// Send verification email, user can request it again on failure.
+	if err := sendEmailVerification(c.UserContext(), db, *user); err != nil {
+		logrus.WithField("user_id", user.ID).Error(err)
+	}
+
 	// Return status 200 OK.
 	return c.JSON(fiber.Map{
 		"error": false,
 		"msg":   nil,
-		"user":  user,
+		"user":  dto.NewSelfUser(*user),
 	})
 }
+
+// rehashPassword func for replacing stored password hash with a fresh one.
+func rehashPassword(ctx context.Context, db *database.Queries, id uuid.UUID, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return db.UpdatePasswordHash(ctx, id, hash)
+}
+
+// SignOut method for logout from the system.
+// @Description Revoke the access token of the request and, if given, the refresh token family.
+// @Summary logout and revoke tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body models.SignOutInput false "refresh token"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/sign-out [post]
+func SignOut(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new SignOutInput struct, body is optional.
+	input := &models.SignOutInput{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(input); err != nil {
+			// Return status 400 and error message.
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+	}
+
+	// Revoke access token of the request.
+	if err := revocation.Default().Revoke(c.UserContext(), tokenMetadata.ID, tokenMetadata.UserId, time.Unix(tokenMetadata.Expires, 0)); err != nil {
+		// Return status 500 and revocation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Remove access cookie from the browser.
+	if utils.CookieModeEnabled() {
+		utils.ClearAuthCookies(c)
+	}
+
+	// Tokens issued before sessions have no session to terminate.
+	if tokenMetadata.SessionID == uuid.Nil && input.RefreshToken == "" {
+		// Return status 204 no content.
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Terminate session of the request with its refresh tokens.
+	if tokenMetadata.SessionID != uuid.Nil {
+		if err := terminateSession(c.UserContext(), db, tokenMetadata.SessionID, tokenMetadata.UserId); err != nil {
+			// Return status 500 and revocation error.
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+	}
+
+	if input.RefreshToken != "" {
+		// Revoke refresh token family, only owner is allowed to do it.
+		foundedToken, err := db.GetRefreshTokenByHash(c.UserContext(), utils.HashToken(input.RefreshToken))
+		if err == nil && foundedToken.UserID == tokenMetadata.UserId {
+			if err := db.RevokeRefreshTokenFamily(c.UserContext(), foundedToken.FamilyID); err != nil {
+				// Return status 500 and database error.
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": true,
+					"msg":   err.Error(),
+				})
+			}
+		}
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SignOutEverywhere method for logout from all devices.
+// @Description Revoke all access and refresh tokens of the current user.
+// @Summary logout from all devices
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/sign-out/all [post]
+func SignOutEverywhere(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke all sessions of the user.
+	if err := db.RevokeUserSessions(c.UserContext(), tokenMetadata.UserId); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke all refresh tokens of the user.
+	if err := db.RevokeUserRefreshTokens(c.UserContext(), tokenMetadata.UserId); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke all access tokens of the user issued until now.
+	if err := revocation.Default().RevokeAll(c.UserContext(), tokenMetadata.UserId); err != nil {
+		// Return status 500 and revocation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Remove access cookie from the browser.
+	if utils.CookieModeEnabled() {
+		utils.ClearAuthCookies(c)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// completeSignIn func for finish sign in of the user, whose first factor is verified.
+// Users with enabled second factor get a short-lived token for /sign-in/mfa instead of access token.
+func completeSignIn(c *fiber.Ctx, db *database.Queries, user models.User) error {
+	// Checking, if the user has enabled second factor.
+	mfa, err := db.GetUserMFA(c.UserContext(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if err == nil && mfa.EnabledAt != nil {
+		// Generate a short-lived token for the second step.
+		mfaToken, err := issueMFAPendingToken(user)
+		if err != nil {
+			// Return status 500 and token generation error.
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+
+		logSignInAttempt(c, db, user.Email, &user.ID, models.SignInMFARequired)
+
+		// Return mfa token, it is exchanged for access token at /sign-in/mfa.
+		return c.JSON(fiber.Map{
+			"error":        false,
+			"msg":          nil,
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+	}
+
+	return grantSignIn(c, db, user)
+}
+
+// grantSignIn func for start a new session of the signed in user and issue its tokens.
+func grantSignIn(c *fiber.Ctx, db *database.Queries, user models.User) error {
+	// Create a new session on the device of the request.
+	session, err := createSession(c, db, user)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Generate a new pair of tokens, the session is a new refresh token family.
+	tokens, _, err := issueTokens(c.UserContext(), db, user, session.ID)
+	if err != nil {
+		// Return status 500 and token generation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Forget failed attempts of the account.
+	if err := db.ResetSignInThrottle(c.UserContext(), accountThrottleKey(user.Email)); err != nil {
+		logrus.WithField("user_id", user.ID).Error(err)
+	}
+	logSignInAttempt(c, db, user.Email, &user.ID, models.SignInSucceeded)
+
+	return tokensResponse(c, tokens)
+}
@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/app/repository"
+	"github.com/popeskul/houser/pkg/rbac"
 	"github.com/popeskul/houser/pkg/utils"
-	"github.com/popeskul/houser/platform/database"
+	"github.com/popeskul/houser/platform/container"
 	"time"
 )
 
@@ -28,8 +32,8 @@ func GetHouse(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Get houses repository.
+	repo, err := container.Houses(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -39,7 +43,7 @@ func GetHouse(c *fiber.Ctx) error {
 	}
 
 	// Get user by ID.
-	user, err := db.GetHouseById(id)
+	user, err := repo.GetHouseById(c.UserContext(), id)
 	if err != nil {
 		// Return, if user not found.
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -66,8 +70,8 @@ func GetHouse(c *fiber.Ctx) error {
 // @Success 200 {array} models.House
 // @Router /v1/houses [get]
 func GetHouses(c *fiber.Ctx) error {
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Get houses repository.
+	repo, err := container.Houses(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -77,7 +81,7 @@ func GetHouses(c *fiber.Ctx) error {
 	}
 
 	// Get all houses.
-	houses, err := db.GetHouses()
+	houses, err := repo.GetHouses(c.UserContext())
 	if err != nil {
 		// Return 404, if users not found.
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -106,6 +110,7 @@ func GetHouses(c *fiber.Ctx) error {
 // @Param input body models.HouseCreateInput true "house info"
 // @Success 200 {object} models.House
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Router /v1/house [post]
 func CreateHouse(c *fiber.Ctx) error {
 	// Get now time.
@@ -142,8 +147,8 @@ func CreateHouse(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Get users and houses repositories.
+	users, err := container.Users(c)
 	if err != nil {
 		// Return status 403 and database connection error.
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
@@ -151,6 +156,33 @@ func CreateHouse(c *fiber.Ctx) error {
 			"msg":   err.Error(),
 		})
 	}
+	repo, err := container.Houses(c)
+	if err != nil {
+		// Return status 403 and database connection error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := users.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Only users with verified email are allowed to create houses.
+	if user.VerifiedAt == nil {
+		// Return status 403 and verification error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   "verify your email before creating houses",
+		})
+	}
 
 	// Create a new validator for a House model.
 	validate := utils.NewValidator()
@@ -170,7 +202,7 @@ func CreateHouse(c *fiber.Ctx) error {
 	}
 
 	// CreateHouse house.
-	if err := db.CreateHouse(house); err != nil {
+	if err := repo.CreateHouse(c.UserContext(), house); err != nil {
 		// Return status 500 and error message.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": true,
@@ -195,6 +227,7 @@ func CreateHouse(c *fiber.Ctx) error {
 // @Param input body models.HouseUpdateInput true "house info"
 // @Success 201 {string} status "ok"
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Router /v1/house [put]
 func UpdateHouse(c *fiber.Ctx) error {
 	// Get now time.
@@ -231,35 +264,6 @@ func UpdateHouse(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
-	if err != nil {
-		// Return status 500 and database connection error.
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
-	}
-
-	// Checking, if house with given ID is exists.
-	foundedHouse, err := db.GetHouseById(house.ID)
-	if err != nil {
-		// Return status 404 and house not found error.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true,
-			"msg":   "house with this ID not found",
-		})
-	}
-
-	// user is house owner
-	if tokenMetadata.UserId != foundedHouse.OwnerID {
-		// Return status 403 and unauthorized error message.
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": true,
-			"msg":   "You don't have permission for update",
-		})
-	}
-
 	// Create a new validator for a House model.
 	validate := utils.NewValidator()
 
@@ -272,16 +276,38 @@ func UpdateHouse(c *fiber.Ctx) error {
 		})
 	}
 
-	// Checking, if house with given ID is exists.
-	err = db.UpdateHouseById(foundedHouse.ID, house)
+	// Get unit of work for houses.
+	tr, err := container.Transactor(c)
 	if err != nil {
-		// Return status 404 and house not found error.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": true,
-			"msg":   "house with this ID not found",
+			"msg":   err.Error(),
 		})
 	}
 
+	// Check owner and update house in one transaction, so nobody changes owner in between.
+	err = tr.InTx(c.UserContext(), func(tx repository.Repositories) error {
+		// Checking, if house with given ID is exists, and lock it.
+		foundedHouse, err := tx.Houses.GetHouseByIdForUpdate(c.UserContext(), house.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errHouseNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// Only house owner or who manages all houses is allowed to change it.
+		if !tokenMetadata.CanManage(foundedHouse.OwnerID, rbac.HousesWrite, rbac.HousesManage) {
+			return errHouseForbidden
+		}
+
+		return tx.Houses.UpdateHouseById(c.UserContext(), foundedHouse.ID, house)
+	})
+	if err != nil {
+		return houseTxError(c, err)
+	}
+
 	return c.SendStatus(fiber.StatusOK)
 }
 
@@ -294,6 +320,7 @@ func UpdateHouse(c *fiber.Ctx) error {
 // @Param input body models.HouseDeleteInput true "house id"
 // @Success 204 {string} status "ok"
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Router /v1/house [delete]
 func DeleteHouse(c *fiber.Ctx) error {
 	// Get now time.
@@ -342,8 +369,8 @@ func DeleteHouse(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Get unit of work for houses.
+	tr, err := container.Transactor(c)
 	if err != nil {
 		// Return status 403 and database connection error.
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
@@ -352,34 +379,49 @@ func DeleteHouse(c *fiber.Ctx) error {
 		})
 	}
 
-	// Checking, if house with given ID is exists.
-	foundedHouse, err := db.GetHouseById(house.ID)
+	// Check owner and delete house in one transaction, so nobody changes owner in between.
+	err = tr.InTx(c.UserContext(), func(tx repository.Repositories) error {
+		// Checking, if house with given ID is exists, and lock it.
+		foundedHouse, err := tx.Houses.GetHouseByIdForUpdate(c.UserContext(), house.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errHouseNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// Only house owner or who manages all houses is allowed to delete it.
+		if !tokenMetadata.CanManage(foundedHouse.OwnerID, rbac.HousesDelete, rbac.HousesManage) {
+			return errHouseForbidden
+		}
+
+		return tx.Houses.DeleteHouseByID(c.UserContext(), foundedHouse.ID)
+	})
 	if err != nil {
-		// Return status 404 and user not found error.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true,
-			"msg":   "user with this ID not found",
-		})
+		return houseTxError(c, err)
 	}
 
-	// user is house owner
-	if tokenMetadata.UserId != foundedHouse.OwnerID {
-		// Return status 403 and unauthorized error message.
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": true,
-			"msg":   "You don't have permission for update",
-		})
-	}
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
 
-	// Delete house by given ID.
-	if err := db.DeleteHouseByID(foundedHouse.ID); err != nil {
-		// Return status 500 and error message.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+var (
+	errHouseNotFound  = errors.New("house with this ID not found")
+	errHouseForbidden = errors.New("You don't have permission for update")
+)
+
+// houseTxError func for return API error of failed house transaction.
+func houseTxError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, errHouseNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, errHouseForbidden):
+		status = fiber.StatusForbidden
 	}
 
-	// Return status 204 no content.
-	return c.SendStatus(fiber.StatusNoContent)
+	return c.Status(status).JSON(fiber.Map{
+		"error": true,
+		"msg":   err.Error(),
+	})
 }
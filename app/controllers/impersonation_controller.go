@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/audit"
+	"github.com/popeskul/houser/platform/container"
+	"time"
+)
+
+// Impersonate func for issue a short-lived access token of the user for the admin.
+// @Description Act on behalf of the user for support. The token has no refresh token, sensitive routes are denied with it, and every request is saved to audit log.
+// @Summary impersonate the user
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/admin/impersonate/{userId} [post]
+func Impersonate(c *fiber.Ctx) error {
+	// Get tokenMetadata of the admin.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Catch user ID from URL.
+	id, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		// Return status 400 and ID error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Checking, if admin tries to impersonate oneself.
+	if id == tokenMetadata.UserId {
+		// Return status 400 and self impersonation error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "you can not impersonate yourself",
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get user by ID.
+	user, err := db.GetUserById(c.UserContext(), id)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with the given ID is not found",
+		})
+	}
+
+	// Admins can not be impersonated, it would give the same rights without trail of the actor.
+	if rbac.Role(user.Role) == rbac.RoleAdmin {
+		// Return status 403 and impersonation error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   "forbidden, admins can not be impersonated",
+		})
+	}
+
+	// Generate a new short-lived access token of the user with the admin as actor.
+	token, tokenID, err := utils.GenerateNewImpersonationToken(user, tokenMetadata.UserId, configs.Default().Impersonation.TTL)
+	if err != nil {
+		// Return status 500 and token generation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Save start of impersonation, token is not given without audit trail.
+	if err := audit.Default().Record(c.UserContext(), &models.ImpersonationAuditEntry{
+		ID:        uuid.New(),
+		ActorID:   tokenMetadata.UserId,
+		UserID:    user.ID,
+		TokenID:   tokenID,
+		Action:    models.ImpersonationStarted,
+		Method:    c.Method(),
+		Path:      c.OriginalURL(),
+		IP:        c.IP(),
+		CreatedAt: time.Now(),
+	}); err != nil {
+		// Return status 500 and audit log error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":      false,
+		"msg":        nil,
+		"expires_in": int(configs.Default().Impersonation.TTL.Seconds()),
+		"tokens": fiber.Map{
+			"access": token,
+		},
+	})
+}
@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/popeskul/houser/app/dto"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/revocation"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// GetMe func gets profile of the current user.
+// @Description Get profile of the user of the token.
+// @Summary get own profile
+// @Tags Me
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.SelfUser
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Router /v1/me [get]
+func GetMe(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"user":  dto.NewSelfUser(user),
+	})
+}
+
+// UpdateMe func for updates profile of the current user.
+// @Description Update only given fields of own profile. Email and password have their own routes.
+// @Summary update own profile
+// @Tags Me
+// @Accept json
+// @Produce json
+// @Param input body models.UpdateMeInput true "profile fields"
+// @Success 200 {object} dto.SelfUser
+// @Security ApiKeyAuth
+// @Router /v1/me [patch]
+func UpdateMe(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new UpdateMeInput struct
+	input := &models.UpdateMeInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a UpdateMeInput model.
+	validate := utils.NewValidator()
+
+	// Validate profile fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Change only given fields.
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+
+	// Update profile of the current user.
+	if err := db.UpdateUser(c.UserContext(), user.ID, &user); err != nil {
+		// Return status 500 and error message.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"user":  dto.NewSelfUser(user),
+	})
+}
+
+// DeleteMe func for deletes account of the current user.
+// @Description Delete own account with proof of the current password. All sessions of the user are terminated.
+// @Summary delete own account
+// @Tags Me
+// @Accept json
+// @Produce json
+// @Param input body models.DeleteMeInput true "current password"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/me [delete]
+func DeleteMe(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new DeleteMeInput struct
+	input := &models.DeleteMeInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a DeleteMeInput model.
+	validate := utils.NewValidator()
+
+	// Validate password field.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Checking, if the current password is known to the caller.
+	if ok, err := checkCurrentPassword(c, db, user, input.CurrentPassword); !ok {
+		return err
+	}
+
+	// Get sessions before they are deleted with the user.
+	sessions, err := db.GetActiveSessions(c.UserContext(), user.ID, time.Time{})
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Delete the current user, tokens, sessions and keys are deleted with it.
+	if err := db.DeleteUser(c.UserContext(), user.ID); err != nil {
+		// Return status 409, if the user still owns houses.
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": true,
+				"msg":   "user owns houses, delete them first",
+			})
+		}
+
+		// Return status 500 and error message.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke access tokens of the sessions and of the request, they are not bound to stored user.
+	for _, id := range append(sessionIDs(sessions), tokenMetadata.ID) {
+		if err := revocation.Default().Revoke(c.UserContext(), id, user.ID, utils.RefreshTokenExpiresAt()); err != nil {
+			logrus.WithField("user_id", user.ID).Error(err)
+		}
+	}
+
+	// Remove access cookie from the browser.
+	if utils.CookieModeEnabled() {
+		utils.ClearAuthCookies(c)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetMyHouses func gets houses of the current user.
+// @Description Get houses owned by the user of the token.
+// @Summary get own houses
+// @Tags Me
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.House
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Router /v1/me/houses [get]
+func GetMyHouses(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get houses of the current user.
+	houses, err := db.GetHousesByOwner(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":  false,
+		"msg":    nil,
+		"count":  len(houses),
+		"houses": houses,
+	})
+}
+
+// sessionIDs func for getting IDs of the sessions.
+func sessionIDs(sessions []models.Session) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,491 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/totp"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"strings"
+	"time"
+)
+
+// recoveryCodesCount is a number of recovery codes given on enrollment.
+const recoveryCodesCount = 10
+
+// EnrollMFA method for start TOTP second factor enrollment.
+// @Description Generate a new TOTP secret for the current user. It is enabled after the first code is confirmed. The current password is required.
+// @Summary start two-factor enrollment
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param input body models.MFAEnrollInput true "current password"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/mfa/enroll [post]
+func EnrollMFA(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new MFAEnrollInput struct
+	input := &models.MFAEnrollInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a MFAEnrollInput model.
+	validate := utils.NewValidator()
+
+	// Validate password field.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Checking, if the current password is known to the caller.
+	if ok, err := checkCurrentPassword(c, db, user, input.CurrentPassword); !ok {
+		return err
+	}
+
+	// Generate a new secret.
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		// Return status 500 and secret generation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Save secret, a previous unconfirmed enrollment is replaced.
+	started, err := db.StartUserMFA(c.UserContext(), &models.UserMFA{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !started {
+		// Return status 409 and conflict error.
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": true,
+			"msg":   "two-factor authentication is already enabled",
+		})
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":            false,
+		"msg":              nil,
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(configs.Default().MFA.Issuer, user.Email, secret),
+	})
+}
+
+// ConfirmMFA method for finish TOTP second factor enrollment.
+// @Description Confirm the first TOTP code, enable two-factor authentication and get one-time recovery codes.
+// @Summary confirm two-factor enrollment
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param input body models.MFACodeInput true "TOTP code"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/mfa/enroll/verify [post]
+func ConfirmMFA(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create new MFACodeInput struct
+	input := &models.MFACodeInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a MFACodeInput model.
+	validate := utils.NewValidator()
+
+	// Validate code fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get started enrollment.
+	mfa, err := db.GetUserMFA(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404, if enrollment was not started.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "two-factor enrollment is not started",
+		})
+	}
+	if mfa.EnabledAt != nil {
+		// Return status 409 and conflict error.
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": true,
+			"msg":   "two-factor authentication is already enabled",
+		})
+	}
+
+	// Check the first code.
+	if ok, err := useTOTPCode(c.UserContext(), db, mfa, input.Code); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	} else if !ok {
+		// Return status 400 and code error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "two-factor code is invalid",
+		})
+	}
+
+	// Generate recovery codes, only their hashes are saved.
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		// Return status 500 and code generation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Enable second factor.
+	if err := db.EnableUserMFA(c.UserContext(), mfa.UserID, hashes); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 200 OK, recovery codes are shown only once.
+	return c.JSON(fiber.Map{
+		"error":          false,
+		"msg":            nil,
+		"recovery_codes": codes,
+	})
+}
+
+// SignInMFA method for finish sign in with the second factor.
+// @Description Exchange "mfa pending" token from sign in and a TOTP or recovery code for access and refresh tokens.
+// @Summary finish sign in with two-factor code
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body models.MFASignInInput true "mfa token and code"
+// @Success 200 {string} status "ok"
+// @Router /v1/sign-in/mfa [post]
+func SignInMFA(c *fiber.Ctx) error {
+	// Create new MFASignInInput struct
+	input := &models.MFASignInInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a MFASignInInput model.
+	validate := utils.NewValidator()
+
+	// Validate sign in fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Verify signature, expiration and purpose of the token.
+	tokenMetadata, err := utils.ParsePurposeToken(input.MFAToken, utils.MFAPendingPurpose)
+	if err != nil {
+		// Return status 401 and token error.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, mfa token is invalid or expired",
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get user and the second factor.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 401, if user was deleted.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, mfa token is invalid or expired",
+		})
+	}
+	mfa, err := db.GetUserMFA(c.UserContext(), user.ID)
+	if err != nil || mfa.EnabledAt == nil {
+		// Return status 401, if second factor was reset meanwhile.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, mfa token is invalid or expired",
+		})
+	}
+
+	// Checking, if the account or the client IP is blocked after failed attempts.
+	attempt, until, locked, err := startSignInAttempt(c, db, user.Email)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if attempt == nil {
+		outcome := models.SignInThrottled
+		if locked {
+			outcome = models.SignInLocked
+		}
+		logSignInAttempt(c, db, user.Email, &user.ID, outcome)
+
+		// Return status 423 or 429 with the time to wait.
+		return signInBlocked(c, until, locked)
+	}
+	defer attempt.finish()
+
+	// Check TOTP code, or recovery code as a fallback.
+	ok, err := useTOTPCode(c.UserContext(), db, mfa, input.Code)
+	if err == nil && !ok {
+		ok, err = db.UseMFARecoveryCode(c.UserContext(), user.ID, utils.HashToken(normalizeRecoveryCode(input.Code)))
+	}
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !ok {
+		// Count failure, so codes can not be guessed.
+		if err := attempt.fail(c.UserContext()); err != nil {
+			// Return status 500 and database error.
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+		logSignInAttempt(c, db, user.Email, &user.ID, models.SignInMFAFailed)
+
+		// Return status 401 and code error.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, two-factor code is invalid",
+		})
+	}
+
+	// Grant tokens, the second factor is verified.
+	return grantSignIn(c, db, user)
+}
+
+// ResetUserMFA method for disable second factor of the user.
+// @Description Disable two-factor authentication of the user, e.g. after the device was lost.
+// @Summary reset two-factor authentication of the user
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param input body models.ResetUserMFAInput true "User ID"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Router /v1/user/mfa [delete]
+func ResetUserMFA(c *fiber.Ctx) error {
+	// Create new ResetUserMFAInput struct
+	input := &models.ResetUserMFAInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a ResetUserMFAInput model.
+	validate := utils.NewValidator()
+
+	// Validate user ID field.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Checking, if the user has second factor.
+	if _, err := db.GetUserMFA(c.UserContext(), input.ID); errors.Is(err, sql.ErrNoRows) {
+		// Return status 404 and second factor not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "two-factor authentication of the user is not found",
+		})
+	} else if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Remove second factor with its recovery codes.
+	if err := db.DeleteUserMFA(c.UserContext(), input.ID); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// issueMFAPendingToken func for generate a short-lived token, which proves the password step of sign in.
+func issueMFAPendingToken(user models.User) (string, error) {
+	token, _, err := utils.GenerateNewPurposeToken(utils.MFAPendingPurpose, user.ID, nil, configs.Default().MFA.PendingTokenTTL)
+
+	return token, err
+}
+
+// useTOTPCode func for check TOTP code and save its time step, so the same code can not be replayed.
+func useTOTPCode(ctx context.Context, db *database.Queries, mfa models.UserMFA, code string) (bool, error) {
+	// Allow clock drift of the configured steps.
+	step, ok := totp.Validate(mfa.Secret, strings.TrimSpace(code), time.Now(), configs.Default().MFA.SkewSteps)
+	if !ok {
+		return false, nil
+	}
+
+	return db.UseMFAStep(ctx, mfa.UserID, step)
+}
+
+// generateRecoveryCodes func for generate one-time recovery codes and their hashes.
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(b))
+		code = code[:8] + "-" + code[8:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode func for ignore case, spaces and dashes in typed recovery code.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/oidc"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"time"
+)
+
+// oidcStateCookie keeps state of authorization request between redirects.
+const oidcStateCookie = "oidc_state"
+
+// OIDCAuthorize method for start sign in with external identity provider.
+// @Description Redirect to the identity provider. It returns back to /v1/oidc/{provider}/callback.
+// @Summary start sign in with identity provider
+// @Tags Auth
+// @Param provider path string true "provider name"
+// @Success 302 {string} status "found"
+// @Router /v1/oidc/{provider}/authorize [get]
+func OIDCAuthorize(c *fiber.Ctx) error {
+	// Get configured provider by name.
+	provider, err := oidc.Default().Get(c.Params("provider"))
+	if err != nil {
+		// Return status 404 and provider error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Keep secrets of the request in state cookie and get URL of the provider.
+	authURL, err := oidcRequestURL(c, provider, uuid.Nil)
+	if err != nil {
+		return oidcRequestError(c, err)
+	}
+
+	// Return status 302 and redirect to the provider.
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// LinkIdentity method for start linking external identity to the current user.
+// @Description Get URL of the identity provider. Identity is linked to the current user, when the provider returns back to /v1/oidc/{provider}/callback.
+// @Summary start linking identity provider
+// @Tags Me
+// @Produce json
+// @Param provider path string true "provider name"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/me/identities/{provider}/authorize [get]
+func LinkIdentity(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get configured provider by name.
+	provider, err := oidc.Default().Get(c.Params("provider"))
+	if err != nil {
+		// Return status 404 and provider error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Keep secrets of the request and the current user in state cookie.
+	authURL, err := oidcRequestURL(c, provider, tokenMetadata.UserId)
+	if err != nil {
+		return oidcRequestError(c, err)
+	}
+
+	// Return status 200 OK, the client opens URL of the provider.
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"url":   authURL,
+	})
+}
+
+// errProviderUnavailable is returned, if URL of the provider can not be discovered.
+var errProviderUnavailable = errors.New("identity provider is not available")
+
+// oidcRequestURL func for start authorization request and get URL of the provider.
+// State, nonce and PKCE verifier are kept in signed state cookie, so the callback
+// can check them. Requests of signed in users carry their ID to link the identity.
+func oidcRequestURL(c *fiber.Ctx, provider *oidc.Provider, userID uuid.UUID) (string, error) {
+	// Generate state, nonce and PKCE verifier of the request.
+	state, err := oidc.NewState()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	// Get URL of the provider.
+	authURL, err := provider.AuthCodeURL(state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errProviderUnavailable, err)
+	}
+
+	// Keep secrets of the request in signed HttpOnly cookie. The browser sends the
+	// verifier back to the callback, only the provider must never see it.
+	stateToken, _, err := utils.GenerateNewPurposeToken(utils.OIDCStatePurpose, userID, map[string]interface{}{
+		"provider": provider.Name,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+	}, 10*time.Minute)
+	if err != nil {
+		return "", err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Path:     "/api/v1/oidc",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+
+	return authURL, nil
+}
+
+// oidcRequestError func for respond with error of oidcRequestURL.
+func oidcRequestError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errProviderUnavailable) {
+		// Return status 502, the provider is not available.
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	return oidcError(c, err)
+}
+
+// OIDCCallback method for finish sign in with external identity provider.
+// @Description Exchange authorization code for access and refresh tokens. External identity is linked to the user with the same verified email, new users are created otherwise. Requests started by /v1/me/identities/{provider}/authorize link the identity to the signed in user.
+// @Summary finish sign in with identity provider
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "provider name"
+// @Param code query string true "authorization code"
+// @Param state query string true "state"
+// @Success 200 {string} status "ok"
+// @Router /v1/oidc/{provider}/callback [get]
+func OIDCCallback(c *fiber.Ctx) error {
+	// Get configured provider by name.
+	provider, err := oidc.Default().Get(c.Params("provider"))
+	if err != nil {
+		// Return status 404 and provider error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// State cookie can be used only once.
+	stateToken := c.Cookies(oidcStateCookie)
+	c.ClearCookie(oidcStateCookie)
+
+	// Checking, if the user denied sign in at the provider.
+	if reason := c.Query("error"); reason != "" {
+		// Return status 401 and provider error.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, identity provider returned " + reason,
+		})
+	}
+
+	// Verify state of the request, it protects from forged callbacks.
+	stateMetadata, err := utils.ParsePurposeToken(stateToken, utils.OIDCStatePurpose)
+	if err != nil ||
+		stateMetadata.Claims["provider"] != provider.Name ||
+		subtle.ConstantTimeCompare([]byte(claimValue(stateMetadata.Claims, "state")), []byte(c.Query("state"))) != 1 {
+		// Return status 400 and state error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "sign in request is invalid or expired, start again",
+		})
+	}
+
+	// Exchange code for verified identity.
+	claims, err := provider.Exchange(c.Query("code"), claimValue(stateMetadata.Claims, "verifier"))
+	if err != nil {
+		// Return status 401 and exchange error.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, " + err.Error(),
+		})
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(claimValue(stateMetadata.Claims, "nonce"))) != 1 {
+		// Return status 401 and replay error.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, ID token was issued for another request",
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Link identity to the signed in user, who started the request.
+	if stateMetadata.UserId != uuid.Nil {
+		err := linkIdentity(c.UserContext(), db, stateMetadata.UserId, provider.Name, claims)
+		if errors.Is(err, errIdentityLinked) {
+			// Return status 409 and identity error.
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+		if err != nil {
+			// Return status 500 and database error.
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+
+		// Return status 200 OK.
+		return c.JSON(fiber.Map{
+			"error": false,
+			"msg":   nil,
+		})
+	}
+
+	// Find or create the user of the identity.
+	user, err := identityUser(c.UserContext(), db, provider.Name, claims)
+	if errors.Is(err, errUnverifiedIdentity) {
+		// Return status 403 and email error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if errors.Is(err, errUnverifiedUser) {
+		// Return status 409, the owner of the email must link identity explicitly.
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": true,
+			"msg":   fmt.Sprintf("%s, sign in and link it with /api/v1/me/identities/%s/authorize", err.Error(), provider.Name),
+		})
+	}
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	return completeSignIn(c, db, user)
+}
+
+// Errors of linking external identities.
+var (
+	errUnverifiedIdentity = errors.New("forbidden, email of the identity is not verified by the provider")
+	errUnverifiedUser     = errors.New("conflict, account with the email of the identity exists, but its email is not verified")
+	errIdentityLinked     = errors.New("conflict, the identity is already linked to another account")
+)
+
+// identityUser func for getting user of the external identity.
+// New identity is linked to the user with the same email, only if both the provider
+// and the app verified it. Otherwise anybody could register the email first and
+// get into the account of its owner, or the other way round.
+func identityUser(ctx context.Context, db *database.Queries, provider string, claims *oidc.Claims) (models.User, error) {
+	// Get already linked identity.
+	identity, err := db.GetUserIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		return db.GetUserById(ctx, identity.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, err
+	}
+
+	// Only verified emails prove, that the identity belongs to the user.
+	if !claims.EmailVerified || claims.Email == "" {
+		return models.User{}, errUnverifiedIdentity
+	}
+
+	// Get user with the same email.
+	user, err := db.Login(ctx, claims.Email)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Create a new user without usable password, it can be set by password reset.
+		user, err = createIdentityUser(ctx, db, claims)
+	case err == nil && user.VerifiedAt == nil:
+		// Unverified email may be registered by somebody else.
+		return models.User{}, errUnverifiedUser
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// Link identity to the user.
+	if err := db.CreateUserIdentity(ctx, &models.UserIdentity{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Provider:  provider,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// linkIdentity func for link external identity to the given user, who proved
+// both accounts by sign in. Email of the identity may differ from email of the user.
+func linkIdentity(ctx context.Context, db *database.Queries, userID uuid.UUID, provider string, claims *oidc.Claims) error {
+	// Checking, if the identity is already linked.
+	identity, err := db.GetUserIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		if identity.UserID != userID {
+			return errIdentityLinked
+		}
+
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Checking, if the user was not deleted after the request was started.
+	if _, err := db.GetUserById(ctx, userID); err != nil {
+		return err
+	}
+
+	return db.CreateUserIdentity(ctx, &models.UserIdentity{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	})
+}
+
+// createIdentityUser func for creates a new user with verified email of the identity.
+func createIdentityUser(ctx context.Context, db *database.Queries, claims *oidc.Claims) (models.User, error) {
+	// Random password nobody knows.
+	secret, _, err := utils.GenerateNewOpaqueToken()
+	if err != nil {
+		return models.User{}, err
+	}
+	hash, err := utils.HashPassword(secret)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+
+	user := models.User{
+		ID:        uuid.New(),
+		Name:      name,
+		Email:     claims.Email,
+		Password:  hash,
+		Role:      string(rbac.DefaultRole),
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateUser(ctx, &user); err != nil {
+		return models.User{}, err
+	}
+
+	// Email is verified by the provider.
+	if _, err := db.VerifyUserEmail(ctx, user.ID, user.Email); err != nil {
+		return models.User{}, err
+	}
+
+	return db.GetUserById(ctx, user.ID)
+}
+
+func claimValue(claims map[string]interface{}, key string) string {
+	value, _ := claims[key].(string)
+
+	return value
+}
+
+func oidcError(c *fiber.Ctx, err error) error {
+	// Return status 500 and sign in error.
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": true,
+		"msg":   err.Error(),
+	})
+}
@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/mailer"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/pkg/worker"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/popeskul/houser/platform/revocation"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+var (
+	passwordResetsOnce sync.Once
+	passwordResets     *worker.Pool
+)
+
+// passwordResetPool func returns the pool, which sends reset emails in background.
+// It is started on first use with settings from .yml file.
+func passwordResetPool() *worker.Pool {
+	passwordResetsOnce.Do(func() {
+		config := configs.Default().PasswordReset
+		passwordResets = worker.NewPool(config.Workers, config.QueueSize, config.SendTimeout)
+	})
+
+	return passwordResets
+}
+
+// ForgotPassword method for request password reset email.
+// @Description Send password reset email. The response is the same for known and unknown emails.
+// @Summary request password reset
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body models.ForgotPasswordInput true "user email"
+// @Success 202 {string} status "ok"
+// @Router /v1/password/forgot [post]
+func ForgotPassword(c *fiber.Ctx) error {
+	// Create new ForgotPasswordInput struct
+	input := &models.ForgotPasswordInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a ForgotPasswordInput model.
+	validate := utils.NewValidator()
+
+	// Validate email fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Checking, if the previous email was sent recently.
+	// Unknown emails and emails without reset tokens take the same single query.
+	latest, err := db.GetLatestPasswordResetTokenByEmail(c.UserContext(), input.Email)
+	switch {
+	case err == nil && time.Since(latest.CreatedAt) < configs.Default().PasswordReset.ResendInterval:
+		// Skip throttled request silently.
+	case err == nil || errors.Is(err, sql.ErrNoRows):
+		// Send email in background, so the response time does not reveal, if the email exists.
+		// The request is over by then, so the job gets its own context limited by send timeout.
+		email := input.Email
+		if !passwordResetPool().Submit(func(ctx context.Context) { sendPasswordReset(ctx, db, email) }) {
+			logrus.Warnf("password reset: queue is full, email is not sent")
+		}
+	default:
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 202 accepted with the same message for every email.
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"error": false,
+		"msg":   "if the email is registered, a password reset link was sent to it",
+	})
+}
+
+// ResetPassword method for set a new password with the token from reset email.
+// @Description Set a new password with the token from reset email. Every token can be used only once, all sessions of the user are signed out and the account is unlocked.
+// @Summary reset password
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body models.ResetPasswordInput true "reset token and new password"
+// @Success 204 {string} status "ok"
+// @Router /v1/password/reset [post]
+func ResetPassword(c *fiber.Ctx) error {
+	// Create new ResetPasswordInput struct
+	input := &models.ResetPasswordInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a ResetPasswordInput model.
+	validate := utils.NewValidator()
+
+	// Validate reset fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get reset token by its hash.
+	foundedToken, err := db.GetPasswordResetTokenByHash(c.UserContext(), utils.HashToken(input.Token))
+	if err != nil || foundedToken.UsedAt != nil || time.Now().After(foundedToken.ExpiresAt) {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "reset token is invalid or expired",
+		})
+	}
+
+	// Get owner of reset token.
+	user, err := db.GetUserById(c.UserContext(), foundedToken.UserID)
+	if err != nil {
+		// Return status 400, if user was deleted.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "reset token is invalid or expired",
+		})
+	}
+
+	// Check password by policy.
+	if err := passwordpolicy.Default().Check(input.Password, user.Email, user.Name); err != nil {
+		// Return, if password is too weak.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Mark token as used, so it can not be used again.
+	used, err := db.UsePasswordResetToken(c.UserContext(), foundedToken.ID)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !used {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "reset token is invalid or expired",
+		})
+	}
+
+	// Hash the new password.
+	hash, err := utils.HashPassword(input.Password)
+	if err != nil {
+		// Return status 500 and hashing error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Save the new password.
+	if err := db.UpdatePasswordHash(c.UserContext(), foundedToken.UserID, hash); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Invalidate other reset links sent to the user.
+	if err := db.UseUserPasswordResetTokens(c.UserContext(), foundedToken.UserID); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke all sessions of the user.
+	if err := db.RevokeUserSessions(c.UserContext(), foundedToken.UserID); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke all refresh tokens of the user.
+	if err := db.RevokeUserRefreshTokens(c.UserContext(), foundedToken.UserID); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Revoke all access tokens of the user issued until now.
+	if err := revocation.Default().RevokeAll(c.UserContext(), foundedToken.UserID); err != nil {
+		// Return status 500 and revocation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Unlock the account, the owner proved access to the email.
+	if err := db.ResetSignInThrottle(c.UserContext(), accountThrottleKey(user.Email)); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// sendPasswordReset func for issue a new reset token and send it to the given email.
+// Unknown emails and throttled requests are skipped silently.
+// Requests are throttled before the job is queued too, the check here catches concurrent requests.
+func sendPasswordReset(ctx context.Context, db *database.Queries, email string) {
+	// Get user by email.
+	user, err := db.Login(ctx, email)
+	if err != nil {
+		return
+	}
+
+	config := configs.Default().PasswordReset
+
+	// Checking, if the previous email was sent recently.
+	if latest, err := db.GetLatestPasswordResetToken(ctx, user.ID); err == nil {
+		if time.Since(latest.CreatedAt) < config.ResendInterval {
+			return
+		}
+	}
+
+	// Generate a new reset token, only its hash is saved.
+	token, hash, err := utils.GenerateNewOpaqueToken()
+	if err != nil {
+		logrus.Errorf("password reset: %v", err)
+		return
+	}
+
+	now := time.Now()
+	if err := db.CreatePasswordResetToken(ctx, &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(config.TTL),
+		CreatedAt: now,
+	}); err != nil {
+		logrus.Errorf("password reset: %v", err)
+		return
+	}
+
+	if err := mailer.Default().Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nset a new password by following the link:\n%s%s\n\nThe link expires in %d minutes. If you did not request it, ignore this email.\n",
+			user.Name, config.URL, token, int(config.TTL.Minutes()),
+		),
+	}); err != nil {
+		logrus.Errorf("password reset: %v", err)
+	}
+}
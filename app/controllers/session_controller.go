@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/popeskul/houser/platform/revocation"
+	"time"
+)
+
+// GetSessions func gets active sessions of the current user.
+// @Description Get devices, where the current user is signed in. Last seen time is updated when tokens are refreshed.
+// @Summary get own active sessions
+// @Tags Session
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Session
+// @Security ApiKeyAuth
+// @Router /v1/me/sessions [get]
+func GetSessions(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Sessions are expired, when refresh token was not used for its whole lifetime.
+	seenAfter := time.Now().Add(-configs.Default().JWT.RefreshTokenTTL)
+
+	// Get active sessions of the user.
+	sessions, err := db.GetActiveSessions(c.UserContext(), tokenMetadata.UserId, seenAfter)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Mark session of the request.
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == tokenMetadata.SessionID
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"msg":      nil,
+		"count":    len(sessions),
+		"sessions": sessions,
+	})
+}
+
+// DeleteSession func for terminates one session of the current user.
+// @Description Sign out the device of the session, its access and refresh tokens are revoked.
+// @Summary terminate session
+// @Tags Session
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/me/sessions/{id} [delete]
+func DeleteSession(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Catch session ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		// Return status 400 and ID error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Checking, if session with given ID is exists and belongs to the user.
+	session, err := db.GetSession(c.UserContext(), id)
+	if err != nil || session.UserID != tokenMetadata.UserId {
+		// Return status 404 and session not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "session with this ID not found",
+		})
+	}
+
+	// Revoke session with its tokens.
+	if err := terminateSession(c.UserContext(), db, session.ID, session.UserID); err != nil {
+		// Return status 500 and revocation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// createSession func for start a new session of the user on the device of the request.
+func createSession(c *fiber.Ctx, db *database.Queries, user models.User) (*models.Session, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		Device:     utils.DeviceName(c.Get(fiber.HeaderUserAgent)),
+		IP:         c.IP(),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	if err := db.CreateSession(c.UserContext(), session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// terminateOtherSessions func for revoke all sessions of the user except the kept one,
+// e.g. after password change. Refresh tokens issued before sessions are revoked too.
+// All access tokens are revoked but the kept one, also tokens without session,
+// e.g. issued by CLI or for impersonation.
+func terminateOtherSessions(ctx context.Context, db *database.Queries, userID, keepSessionID, keepTokenID uuid.UUID) error {
+	// Mark other sessions as revoked.
+	if _, err := db.RevokeOtherUserSessions(ctx, userID, keepSessionID); err != nil {
+		return err
+	}
+
+	// Revoke refresh tokens of other sessions.
+	if err := db.RevokeOtherUserRefreshTokens(ctx, userID, keepSessionID); err != nil {
+		return err
+	}
+
+	// Revoke all access tokens of the user issued until now, but the kept one.
+	return revocation.Default().RevokeAllExcept(ctx, userID, keepTokenID)
+}
+
+// terminateSession func for revoke session, its refresh tokens and access tokens.
+func terminateSession(ctx context.Context, db *database.Queries, sessionID, userID uuid.UUID) error {
+	// Mark session as revoked.
+	if err := db.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	// Revoke refresh tokens of the session.
+	if err := db.RevokeRefreshTokenFamily(ctx, sessionID); err != nil {
+		return err
+	}
+
+	// Revoke access tokens of the session, they are never valid longer than the session.
+	return revocation.Default().Revoke(ctx, sessionID, userID, utils.RefreshTokenExpiresAt())
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/app/queries"
+	"github.com/popeskul/houser/pkg/throttle"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetSignInAttempts func gets the last sign in attempts of the current user.
+// @Description Get the last sign in attempts of the current user with IP, user agent and outcome.
+// @Summary get own sign in attempts
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param limit query int false "attempts count, 50 by default, 200 at most"
+// @Success 200 {array} models.SignInAttempt
+// @Security ApiKeyAuth
+// @Router /v1/sign-in-attempts [get]
+func GetSignInAttempts(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get attempts count from query.
+	limit := c.Query("limit", "50")
+	count, err := strconv.Atoi(limit)
+	if err != nil || count < 1 || count > 200 {
+		// Return status 400 and limit error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "limit must be a number from 1 to 200",
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get the last attempts of the user.
+	attempts, err := db.GetSignInAttempts(c.UserContext(), tokenMetadata.UserId, count)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"msg":      nil,
+		"count":    len(attempts),
+		"attempts": attempts,
+	})
+}
+
+// UnlockUser func for removes sign in lockout of the user.
+// @Description Remove sign in lockout and forget failed attempts of the user.
+// @Summary unlock sign in of the user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param input body models.UnlockUserInput true "User ID"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Router /v1/user/lockout [delete]
+func UnlockUser(c *fiber.Ctx) error {
+	// Create new UnlockUserInput struct
+	input := &models.UnlockUserInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a UnlockUserInput model.
+	validate := utils.NewValidator()
+
+	// Validate user ID field.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Checking, if user with given ID is exists.
+	user, err := db.GetUserById(c.UserContext(), input.ID)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Forget failed attempts of the account.
+	if err := db.ResetSignInThrottle(c.UserContext(), accountThrottleKey(user.Email)); err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// accountThrottleKey func for getting throttle key of the account, unknown emails are throttled too.
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// ipThrottleKey func for getting throttle key of the client IP.
+func ipThrottleKey(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// signInAttempt struct to describe sign in attempt, which keeps throttles of the account
+// and the client IP locked until it is finished. Parallel attempts can not pass the check,
+// before failure of this one is counted.
+type signInAttempt struct {
+	tx       *database.SignInThrottleTx
+	policies map[string]throttle.Policy
+}
+
+// startSignInAttempt func for locking throttles of the account and the client IP.
+// If they are blocked after failed attempts or another attempt with them is in progress,
+// no attempt is started and the time to wait is returned. It reports true for lockout.
+func startSignInAttempt(c *fiber.Ctx, db *database.Queries, email string) (*signInAttempt, time.Time, bool, error) {
+	policies := map[string]throttle.Policy{
+		accountThrottleKey(email): throttle.AccountPolicy(),
+		ipThrottleKey(c):          throttle.IPPolicy(),
+	}
+
+	tx, throttles, err := db.LockSignInThrottles(c.UserContext(), accountThrottleKey(email), ipThrottleKey(c))
+	if errors.Is(err, queries.ErrSignInThrottleBusy) {
+		return nil, time.Now().Add(time.Second), false, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+
+	// Checking, if the account or the client IP is blocked.
+	var until time.Time
+	var locked bool
+	for _, t := range throttles {
+		if t.BlockedUntil != nil && t.BlockedUntil.After(until) {
+			until = *t.BlockedUntil
+			locked = t.Locked
+		}
+	}
+
+	if until.After(time.Now()) {
+		_ = tx.Rollback()
+		return nil, until, locked, nil
+	}
+
+	return &signInAttempt{tx: tx, policies: policies}, time.Time{}, false, nil
+}
+
+// fail method for counting failure of the account and the client IP,
+// further attempts are blocked by their policies. It finishes the attempt.
+func (a *signInAttempt) fail(ctx context.Context) error {
+	for key, policy := range a.policies {
+		failures, err := a.tx.RecordSignInFailure(ctx, key, policy.Window)
+		if err != nil {
+			_ = a.tx.Rollback()
+			return err
+		}
+
+		if delay, locked := policy.Block(failures); delay > 0 {
+			if err := a.tx.BlockSignIn(ctx, key, time.Now().Add(delay), locked); err != nil {
+				_ = a.tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return a.tx.Commit()
+}
+
+// finish method for unlocking throttles of successful attempt, it does nothing after fail.
+func (a *signInAttempt) finish() {
+	_ = a.tx.Commit()
+}
+
+// signInBlocked func for respond to blocked sign in attempt.
+func signInBlocked(c *fiber.Ctx, until time.Time, locked bool) error {
+	// Tell the client, when it may try again.
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(time.Until(until).Seconds())+1))
+
+	if locked {
+		// Return status 423 and lockout error.
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"error": true,
+			"msg":   "account is temporarily locked after too many failed sign in attempts",
+		})
+	}
+
+	// Return status 429 and backoff error.
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error": true,
+		"msg":   "too many failed sign in attempts, try again later",
+	})
+}
+
+// logSignInAttempt func for saving sign in attempt, it never fails the sign in.
+func logSignInAttempt(c *fiber.Ctx, db *database.Queries, email string, userID *uuid.UUID, outcome string) {
+	attempt := &models.SignInAttempt{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Email:     email,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Outcome:   outcome,
+		CreatedAt: time.Now(),
+	}
+
+	if err := db.CreateSignInAttempt(c.UserContext(), attempt); err != nil {
+		logrus.WithField("email", email).Error(err)
+	}
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/keystore"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// RefreshToken method for renew access and refresh tokens.
+// @Description Renew access and refresh tokens. Every refresh token can be used only once. In cookie mode refresh token is read from HttpOnly cookie and the body is not needed.
+// @Summary renew access and refresh tokens
+// @Tags Token
+// @Accept json
+// @Produce json
+// @Param input body models.RefreshTokenInput false "refresh token"
+// @Success 200 {string} status "ok"
+// @Router /v1/token/refresh [post]
+func RefreshToken(c *fiber.Ctx) error {
+	// Create new RefreshTokenInput struct
+	input := &models.RefreshTokenInput{}
+
+	// Browsers in cookie mode send refresh token in HttpOnly cookie.
+	if utils.CookieModeEnabled() {
+		input.RefreshToken = utils.RefreshCookie(c)
+	}
+
+	// Otherwise it is read from the body.
+	if input.RefreshToken == "" {
+		// Check, if received JSON data is valid.
+		if err := c.BodyParser(input); err != nil {
+			// Return status 400 and error message.
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": true,
+				"msg":   err.Error(),
+			})
+		}
+
+		// Create a new validator for a RefreshTokenInput model.
+		validate := utils.NewValidator()
+
+		// Validate refresh token fields.
+		if err := validate.Struct(input); err != nil {
+			// Return, if some fields are not valid.
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": true,
+				"msg":   utils.ValidatorErrors(err),
+			})
+		}
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get refresh token by its hash.
+	foundedToken, err := db.GetRefreshTokenByHash(c.UserContext(), utils.HashToken(input.RefreshToken))
+	if err != nil {
+		// Return status 401, if refresh token not found.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, refresh token is invalid",
+		})
+	}
+
+	// Already rotated token was used again, so the whole family is compromised.
+	if foundedToken.RevokedAt != nil {
+		return revokeRefreshTokenFamily(c, db, foundedToken.FamilyID, foundedToken.UserID)
+	}
+
+	// Checking, if now time greater than expiration of refresh token.
+	if time.Now().After(foundedToken.ExpiresAt) {
+		// Return status 401 and unauthorized error message.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, refresh token is expired",
+		})
+	}
+
+	// Get owner of refresh token.
+	user, err := db.GetUserById(c.UserContext(), foundedToken.UserID)
+	if err != nil {
+		// Return status 401, if user was deleted.
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": true,
+			"msg":   "unauthorized, refresh token is invalid",
+		})
+	}
+
+	// Generate a new pair of tokens in the same family.
+	tokens, newToken, err := issueTokens(c.UserContext(), db, user, foundedToken.FamilyID)
+	if err != nil {
+		// Return status 500 and token generation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Mark used refresh token as replaced by the new one.
+	rotated, err := db.RotateRefreshToken(c.UserContext(), foundedToken.ID, newToken.ID)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Token was rotated by a concurrent request, treat it as a reuse.
+	if !rotated {
+		return revokeRefreshTokenFamily(c, db, foundedToken.FamilyID, foundedToken.UserID)
+	}
+
+	// Session of the token is active, tokens issued before sessions have none.
+	if err := db.TouchSession(c.UserContext(), foundedToken.FamilyID, c.IP()); err != nil {
+		logrus.WithField("session_id", foundedToken.FamilyID).Error(err)
+	}
+
+	return tokensResponse(c, tokens)
+}
+
+// GetJWKS func gets public keys in JSON Web Key Set format,
+// so other services can verify access tokens without the signing secret.
+// It is served outside of the API base path at /.well-known/jwks.json.
+func GetJWKS(c *fiber.Ctx) error {
+	// Keys are rotated rarely, allow clients to cache them for a while.
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	// Return status 200 OK.
+	return c.JSON(keystore.Default().JWKS())
+}
+
+// issueTokens func for generate a new pair of access and refresh tokens.
+// Refresh token is saved to the database as a member of the given family,
+// the family ID is the session ID of access token.
+func issueTokens(ctx context.Context, db *database.Queries, user models.User, familyID uuid.UUID) (*utils.Tokens, *models.RefreshToken, error) {
+	// Generate a new Access token.
+	accessToken, err := utils.GenerateNewAccessToken(user, familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Generate a new Refresh token.
+	refreshToken, refreshTokenHash, err := utils.GenerateNewRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Save Refresh token hash.
+	newToken := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshTokenHash,
+		ExpiresAt: utils.RefreshTokenExpiresAt(),
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateRefreshToken(ctx, newToken); err != nil {
+		return nil, nil, err
+	}
+
+	return &utils.Tokens{Access: accessToken, Refresh: refreshToken}, newToken, nil
+}
+
+// tokensResponse func for return issued tokens with status 200 OK.
+// In cookie mode access and refresh tokens are set in HttpOnly cookies instead
+// of the body, so scripts can not read them, and the body has CSRF token for
+// state-changing requests.
+func tokensResponse(c *fiber.Ctx, tokens *utils.Tokens) error {
+	if !utils.CookieModeEnabled() {
+		return c.JSON(fiber.Map{
+			"error":         false,
+			"msg":           nil,
+			"access_token":  tokens.Access,
+			"refresh_token": tokens.Refresh,
+		})
+	}
+
+	// Set access token, refresh token and CSRF token cookies.
+	csrfToken, err := utils.SetAuthCookies(c, tokens.Access, utils.AccessTokenExpiresAt())
+	if err != nil {
+		// Return status 500 and token generation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	utils.SetRefreshCookie(c, tokens.Refresh, utils.RefreshTokenExpiresAt())
+
+	return c.JSON(fiber.Map{
+		"error":      false,
+		"msg":        nil,
+		"csrf_token": csrfToken,
+	})
+}
+
+// revokeRefreshTokenFamily func for terminate session of the family after reuse.
+// Family ID is the session ID, so access tokens of the session are revoked too,
+// a stolen refresh token must not leave valid access tokens behind.
+func revokeRefreshTokenFamily(c *fiber.Ctx, db *database.Queries, familyID, userID uuid.UUID) error {
+	// Revoke the session, all refresh tokens of the family and their access tokens.
+	if err := terminateSession(c.UserContext(), db, familyID, userID); err != nil {
+		// Return status 500 and revocation error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 401 and unauthorized error message.
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": true,
+		"msg":   "unauthorized, refresh token was already used, sign in again",
+	})
+}
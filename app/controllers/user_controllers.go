@@ -3,9 +3,13 @@ package controllers
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/dto"
 	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
+	"github.com/popeskul/houser/pkg/rbac"
 	"github.com/popeskul/houser/pkg/utils"
-	"github.com/popeskul/houser/platform/database"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/sirupsen/logrus"
 	"time"
 )
 
@@ -16,7 +20,7 @@ import (
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID"
-// @Success 200 {object} models.User
+// @Success 200 {object} dto.PublicUser
 // @Router /v1/user/{id} [get]
 func GetUser(c *fiber.Ctx) error {
 	// Catch user ID from URL.
@@ -28,8 +32,8 @@ func GetUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Get users repository.
+	repo, err := container.Users(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -39,7 +43,7 @@ func GetUser(c *fiber.Ctx) error {
 	}
 
 	// Get user by ID.
-	user, err := db.GetUserById(id)
+	user, err := repo.GetUserById(c.UserContext(), id)
 	if err != nil {
 		// Return, if user not found.
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -53,21 +57,33 @@ func GetUser(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"error": false,
 		"msg":   nil,
-		"user":  user,
+		"user":  dto.NewPublicUser(user),
 	})
 }
 
 // GetUsers godoc.
-// @Description Get all exists users.
+// @Description Get all exists users. Admins get full profiles, other users get public profiles.
 // @Summary gets all exists users
 // @Tags Users
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.User
+// @Success 200 {array} dto.PublicUser
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Router /v1/users [get]
 func GetUsers(c *fiber.Ctx) error {
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get users repository.
+	repo, err := container.Users(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -77,7 +93,7 @@ func GetUsers(c *fiber.Ctx) error {
 	}
 
 	// Get all users.
-	users, err := db.GetUsers()
+	users, err := repo.GetUsers(c.UserContext())
 	if err != nil {
 		// Return, if users not found.
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -93,7 +109,7 @@ func GetUsers(c *fiber.Ctx) error {
 		"error": false,
 		"msg":   nil,
 		"count": len(users),
-		"users": users,
+		"users": usersView(tokenMetadata, users),
 	})
 }
 
@@ -104,8 +120,9 @@ func GetUsers(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param input body models.UserCreateInput true "user info"
-// @Success 200 {object} models.User
+// @Success 200 {object} dto.AdminUser
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Router /v1/user [post]
 func CreateUser(c *fiber.Ctx) error {
 	// Get now time.
@@ -130,11 +147,11 @@ func CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create new User struct
-	user := &models.User{}
+	// Create new UserCreateInput struct
+	input := &models.UserCreateInput{}
 
 	// Check, if received JSON data is valid.
-	if err := c.BodyParser(user); err != nil {
+	if err := c.BodyParser(input); err != nil {
 		// Return status 400 and error message
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": true,
@@ -142,8 +159,11 @@ func CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Create new User struct from input.
+	user := &models.User{Name: input.Name, Email: input.Email, Password: input.Password, Role: input.Role}
+
+	// Get database connection pool, verification tokens are kept there too.
+	db, err := container.DB(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -157,7 +177,11 @@ func CreateUser(c *fiber.Ctx) error {
 
 	// Set initialized default data for user:
 	user.ID = uuid.New()
+	user.VerifiedAt = nil
 	user.CreatedAt = time.Now()
+	if user.Role == "" {
+		user.Role = string(rbac.DefaultRole)
+	}
 
 	// Validate user fields.
 	if err := validate.Struct(user); err != nil {
@@ -168,8 +192,27 @@ func CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Delete user by given ID.
-	if err := db.CreateUser(user); err != nil {
+	// Check password by policy.
+	if err := passwordpolicy.Default().Check(user.Password, user.Email, user.Name); err != nil {
+		// Return, if password is too weak.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Replace given password with its hash.
+	user.Password, err = utils.HashPassword(user.Password)
+	if err != nil {
+		// Return status 500 and password hashing error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new user.
+	if err := db.CreateUser(c.UserContext(), user); err != nil {
 		// Return status 500 and error message.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": true,
@@ -177,16 +220,21 @@ func CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Send verification email, user can request it again on failure.
+	if err := sendEmailVerification(c.UserContext(), db, *user); err != nil {
+		logrus.WithField("user_id", user.ID).Error(err)
+	}
+
 	// Return status 200 OK.
 	return c.JSON(fiber.Map{
 		"error": false,
 		"msg":   nil,
-		"user":  user,
+		"user":  dto.NewAdminUser(*user),
 	})
 }
 
 // UpdateUser func for updates user by given ID.
-// @Description Update user.
+// @Description Update profile of the user, only the user oneself or admin can do it. Email and password are changed by the user only, see /v1/me/email and /v1/me/password.
 // @Summary update user
 // @Tags User
 // @Accept json
@@ -221,11 +269,11 @@ func UpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create new User struct
-	user := &models.User{}
+	// Create new UserUpdateInput struct
+	input := &models.UserUpdateInput{}
 
 	// Check, if received JSON data is valid.
-	if err := c.BodyParser(user); err != nil {
+	if err := c.BodyParser(input); err != nil {
 		// Return status 400 and error message.
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": true,
@@ -233,8 +281,29 @@ func UpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Checking, if the profile belongs to the user, or the user is admin.
+	if !tokenMetadata.CanManage(input.ID, rbac.ProfileWrite, rbac.UsersWrite) {
+		// Return status 403 and permission error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   "forbidden, only the user or admin can update the profile",
+		})
+	}
+
+	// Create a new validator for a UserUpdateInput model.
+	validate := utils.NewValidator()
+
+	// Validate profile fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Get users repository.
+	repo, err := container.Users(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -244,7 +313,7 @@ func UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// Checking, if user with given ID is exists.
-	foundedUser, err := db.GetUserById(user.ID)
+	foundedUser, err := repo.GetUserById(c.UserContext(), input.ID)
 	if err != nil {
 		// Return status 404 and user not found error.
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -253,20 +322,24 @@ func UpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create a new validator for a User model.
-	validate := utils.NewValidator()
-
-	// Validate user fields.
-	if err := validate.Struct(user); err != nil {
-		// Return, if some fields are not valid.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+	// Checking, if role is changed by the user without permission.
+	if input.Role != "" && input.Role != foundedUser.Role && !tokenMetadata.Can(rbac.UsersWrite) {
+		// Return status 403 and permission error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": true,
-			"msg":   utils.ValidatorErrors(err),
+			"msg":   "forbidden, permission " + string(rbac.UsersWrite) + " is required to change role",
 		})
 	}
 
+	// Change only profile fields, keep current role, if it is not given.
+	user := &foundedUser
+	user.Name = input.Name
+	if input.Role != "" {
+		user.Role = input.Role
+	}
+
 	// Update user by given ID.
-	if err := db.UpdateUser(foundedUser.ID, user); err != nil {
+	if err := repo.UpdateUser(c.UserContext(), foundedUser.ID, user); err != nil {
 		// Return status 500 and error message.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": true,
@@ -279,7 +352,7 @@ func UpdateUser(c *fiber.Ctx) error {
 }
 
 // DeleteUser func for deletes user by given ID.
-// @Description Delete user by given ID.
+// @Description Delete other user by given ID, only admin can do it. Own account is deleted with the current password, see /v1/me.
 // @Summary delete user by given ID
 // @Tags User
 // @Accept json
@@ -311,8 +384,8 @@ func DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create new User struct
-	user := &models.User{}
+	// Create new UserDeleteInput struct
+	user := &models.UserDeleteInput{}
 
 	// Check, if received JSON data is valid.
 	if err := c.BodyParser(user); err != nil {
@@ -323,11 +396,29 @@ func DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create a new validator for a User model.
+	// Own account is deleted with the current password only, see DeleteMe.
+	if user.ID == tokenMetadata.UserId {
+		// Return status 403 and route error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   "forbidden, use DELETE /api/v1/me to delete own account",
+		})
+	}
+
+	// Checking, if the user may delete other accounts.
+	if !tokenMetadata.Can(rbac.UsersDelete) {
+		// Return status 403 and permission error.
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   "forbidden, only admin can delete the account",
+		})
+	}
+
+	// Create a new validator for a UserDeleteInput model.
 	validate := utils.NewValidator()
 
-	// Validate only one user field ID.
-	if err := validate.StructPartial(user, "id"); err != nil {
+	// Validate user ID field.
+	if err := validate.Struct(user); err != nil {
 		// Return, if some fields are not valid.
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": true,
@@ -335,8 +426,8 @@ func DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create database connection.
-	db, err := database.OpenDBConnection()
+	// Get users repository.
+	repo, err := container.Users(c)
 	if err != nil {
 		// Return status 500 and database connection error.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -346,7 +437,7 @@ func DeleteUser(c *fiber.Ctx) error {
 	}
 
 	// Checking, if user with given ID is exists.
-	foundedUser, err := db.GetUserById(user.ID)
+	foundedUser, err := repo.GetUserById(c.UserContext(), user.ID)
 	if err != nil {
 		// Return status 404 and user not found error.
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -356,7 +447,7 @@ func DeleteUser(c *fiber.Ctx) error {
 	}
 
 	// Delete user by given ID.
-	if err := db.DeleteUser(foundedUser.ID); err != nil {
+	if err := repo.DeleteUser(c.UserContext(), foundedUser.ID); err != nil {
 		// Return status 500 and error message.
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": true,
@@ -367,3 +458,13 @@ func DeleteUser(c *fiber.Ctx) error {
 	// Return status 204 no content.
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// usersView func for map users to the view allowed for the request.
+func usersView(tokenMetadata *utils.TokenMetadata, users []models.User) interface{} {
+	// Only admins see emails and roles of other users.
+	if tokenMetadata.Can(rbac.UsersWrite) {
+		return dto.NewAdminUsers(users)
+	}
+
+	return dto.NewPublicUsers(users)
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/mailer"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"strconv"
+	"time"
+)
+
+// VerifyEmail method for confirm email address of the user.
+// @Description Confirm email address with the token from verification email. Every token can be used only once.
+// @Summary confirm email address
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body models.VerifyEmailInput true "verification token"
+// @Success 204 {string} status "ok"
+// @Router /v1/verify-email [post]
+func VerifyEmail(c *fiber.Ctx) error {
+	// Create new VerifyEmailInput struct
+	input := &models.VerifyEmailInput{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(input); err != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Create a new validator for a VerifyEmailInput model.
+	validate := utils.NewValidator()
+
+	// Validate verification fields.
+	if err := validate.Struct(input); err != nil {
+		// Return, if some fields are not valid.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   utils.ValidatorErrors(err),
+		})
+	}
+
+	// Verify signature, expiration and purpose of the token.
+	tokenMetadata, err := utils.ParsePurposeToken(input.Token, utils.EmailVerificationPurpose)
+	if err != nil {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "verification token is invalid or expired",
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get issued token, it keeps the verified email.
+	foundedToken, err := db.GetEmailVerificationToken(c.UserContext(), tokenMetadata.ID)
+	if err != nil || foundedToken.UserID != tokenMetadata.UserId {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "verification token is invalid or expired",
+		})
+	}
+
+	// Mark token as used, so it can not be used again.
+	used, err := db.UseEmailVerificationToken(c.UserContext(), foundedToken.ID)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !used {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "verification token was already used",
+		})
+	}
+
+	// Mark email as verified, if it was not changed since the token was issued.
+	verified, err := db.VerifyUserEmail(c.UserContext(), foundedToken.UserID, foundedToken.Email)
+	if err != nil {
+		// Return status 500 and database error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+	if !verified {
+		// Return status 400 and token error.
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": true,
+			"msg":   "verification token does not match the current email",
+		})
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ResendEmailVerification method for send verification email again.
+// @Description Send a new verification email to the current user. It is throttled.
+// @Summary resend verification email
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 202 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/verify-email/resend [post]
+func ResendEmailVerification(c *fiber.Ctx) error {
+	// Get tokenMetadata from JWT.
+	tokenMetadata, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get database connection pool.
+	db, err := container.DB(c)
+	if err != nil {
+		// Return status 500 and database connection error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Get current user.
+	user, err := db.GetUserById(c.UserContext(), tokenMetadata.UserId)
+	if err != nil {
+		// Return status 404 and user not found error.
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": true,
+			"msg":   "user with this ID not found",
+		})
+	}
+
+	// Checking, if email is already verified.
+	if user.VerifiedAt != nil {
+		// Return status 409 and conflict error.
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": true,
+			"msg":   "email is already verified",
+		})
+	}
+
+	// Checking, if the previous email was sent recently.
+	if latest, err := db.GetLatestEmailVerificationToken(c.UserContext(), user.ID); err == nil {
+		if wait := time.Until(latest.CreatedAt.Add(configs.Default().EmailVerification.ResendInterval)); wait > 0 {
+			// Return status 429 and the time to wait.
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())+1))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": true,
+				"msg":   "verification email was sent recently, try again later",
+			})
+		}
+	}
+
+	// Send a new verification email.
+	if err := sendEmailVerification(c.UserContext(), db, user); err != nil {
+		// Return status 500 and mail error.
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 202 accepted.
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// sendEmailVerification func for issue a new verification token and send it to the user email.
+func sendEmailVerification(ctx context.Context, db *database.Queries, user models.User) error {
+	config := configs.Default().EmailVerification
+
+	// Generate a new signed verification token.
+	token, jti, err := utils.GenerateNewPurposeToken(utils.EmailVerificationPurpose, user.ID, nil, config.TTL)
+	if err != nil {
+		return err
+	}
+
+	// Save token ID, so it can be used only once.
+	now := time.Now()
+	if err := db.CreateEmailVerificationToken(ctx, &models.EmailVerificationToken{
+		ID:        jti,
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: now.Add(config.TTL),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	return mailer.Default().Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nconfirm your email address by following the link:\n%s%s\n\nThe link expires in %d hours.\n",
+			user.Name, config.URL, token, int(config.TTL.Hours()),
+		),
+	})
+}
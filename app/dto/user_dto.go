@@ -0,0 +1,91 @@
+// Package dto describes objects returned by API.
+// Models are mapped to them, so stored fields like password hashes are never serialized.
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"time"
+)
+
+// PublicUser struct to describe profile of the user, which is visible to anyone.
+type PublicUser struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SelfUser struct to describe profile of the user, which is visible to the user oneself.
+type SelfUser struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AdminUser struct to describe profile of the user, which is visible to admins.
+type AdminUser struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewPublicUser func for map user to its public profile.
+func NewPublicUser(user models.User) PublicUser {
+	return PublicUser{
+		ID:        user.ID,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// NewSelfUser func for map user to its own profile.
+func NewSelfUser(user models.User) SelfUser {
+	return SelfUser{
+		ID:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Role:       user.Role,
+		VerifiedAt: user.VerifiedAt,
+		CreatedAt:  user.CreatedAt,
+	}
+}
+
+// NewAdminUser func for map user to profile for admins.
+func NewAdminUser(user models.User) AdminUser {
+	return AdminUser{
+		ID:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Role:       user.Role,
+		Verified:   user.VerifiedAt != nil,
+		VerifiedAt: user.VerifiedAt,
+		CreatedAt:  user.CreatedAt,
+	}
+}
+
+// NewPublicUsers func for map list of users to their public profiles.
+func NewPublicUsers(users []models.User) []PublicUser {
+	profiles := make([]PublicUser, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, NewPublicUser(user))
+	}
+
+	return profiles
+}
+
+// NewAdminUsers func for map list of users to profiles for admins.
+func NewAdminUsers(users []models.User) []AdminUser {
+	profiles := make([]AdminUser, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, NewAdminUser(user))
+	}
+
+	return profiles
+}
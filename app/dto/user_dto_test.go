@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserViewsHaveNoCredentials(t *testing.T) {
+	now := time.Now()
+	hash := "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA"
+	user := models.User{
+		ID:         uuid.New(),
+		Name:       "Test",
+		Email:      "test@mail.com",
+		Password:   hash,
+		Role:       "owner",
+		VerifiedAt: &now,
+		CreatedAt:  now,
+	}
+
+	views := map[string]interface{}{
+		"model":        user,
+		"public":       NewPublicUser(user),
+		"self":         NewSelfUser(user),
+		"admin":        NewAdminUser(user),
+		"public list":  NewPublicUsers([]models.User{user}),
+		"admin list":   NewAdminUsers([]models.User{user}),
+		"model in map": map[string]interface{}{"user": user},
+	}
+
+	for name, view := range views {
+		b, err := json.Marshal(view)
+		assert.NoErrorf(t, err, name)
+
+		body := strings.ToLower(string(b))
+		assert.NotContainsf(t, body, "password", name)
+		assert.NotContainsf(t, body, strings.ToLower(hash), name)
+		assert.NotContainsf(t, body, "argon2id", name)
+	}
+
+	// Public profile is not a way to collect emails.
+	b, err := json.Marshal(NewPublicUser(user))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), user.Email)
+}
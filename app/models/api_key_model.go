@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+// APIKey struct to describe personal API key of the user.
+// Only the hash of the key is stored, prefix is kept to recognize the key in lists.
+type APIKey struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	UserID     uuid.UUID      `json:"user_id" db:"user_id"`
+	Name       string         `json:"name" db:"name"`
+	Prefix     string         `json:"prefix" db:"prefix"`
+	KeyHash    string         `json:"-" db:"key_hash"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes" swaggertype:"array,string"`
+	ExpiresAt  *time.Time     `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+}
+
+type APIKeyCreateInput struct {
+	Name      string     `json:"name" validate:"required,lte=255"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyRevokeInput struct {
+	ID uuid.UUID `json:"id" validate:"required,uuid"`
+}
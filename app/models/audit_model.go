@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Actions of impersonation audit log.
+const (
+	ImpersonationStarted = "start"
+	ImpersonatedRequest  = "request"
+)
+
+// ImpersonationAuditEntry struct to describe one action of the actor on behalf of the user.
+// Users are not referenced by foreign keys, the log outlives deleted users.
+type ImpersonationAuditEntry struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	ActorID   uuid.UUID `json:"actor_id" db:"actor_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	TokenID   uuid.UUID `json:"token_id" db:"token_id"`
+	Action    string    `json:"action" db:"action"`
+	Method    string    `json:"method" db:"method"`
+	Path      string    `json:"path" db:"path"`
+	IP        string    `json:"ip" db:"ip"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -2,11 +2,11 @@ package models
 
 type SignInInput struct {
 	Email    string `json:"email" db:"email" validate:"email"`
-	Password string `json:"password" db:"password" validate:"lte=30"`
+	Password string `json:"password" db:"password" validate:"lte=1024"` // long enough for passphrases, limits hashing cost
 }
 
 type SignUpInput struct {
 	Name     string `json:"name" db:"name" validate:"lte=30"`
 	Email    string `json:"email" db:"email" validate:"email"`
-	Password string `json:"password" db:"password" validate:"lte=30"`
+	Password string `json:"password" db:"password" validate:"required"`
 }
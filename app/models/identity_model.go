@@ -0,0 +1,16 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// UserIdentity struct to describe external identity linked to the user.
+type UserIdentity struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Provider  string    `json:"provider" db:"provider"`
+	Subject   string    `json:"subject" db:"subject"`
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
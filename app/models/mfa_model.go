@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// UserMFA struct to describe TOTP second factor of the user.
+// It is enabled only after the first code was confirmed.
+type UserMFA struct {
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Secret       string     `json:"-" db:"secret"`
+	EnabledAt    *time.Time `json:"enabled_at" db:"enabled_at"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MFARecoveryCode struct to describe one-time recovery code, only its hash is stored.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+type MFAEnrollInput struct {
+	CurrentPassword string `json:"current_password" validate:"required,lte=1024"`
+}
+
+type MFACodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFASignInInput struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type ResetUserMFAInput struct {
+	ID uuid.UUID `json:"id" validate:"required,uuid"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Session struct to describe one sign in of the user on a device.
+// Its ID is the family ID of refresh tokens and "sid" claim of access tokens.
+type Session struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Device     string     `json:"device" db:"device"`
+	IP         string     `json:"ip" db:"ip"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	Current    bool       `json:"current" db:"-"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Outcomes of sign in attempts.
+const (
+	SignInSucceeded   = "success"
+	SignInMFARequired = "mfa_required"
+	SignInFailed      = "invalid_credentials"
+	SignInMFAFailed   = "invalid_mfa_code"
+	SignInThrottled   = "throttled"
+	SignInLocked      = "locked"
+)
+
+// SignInAttempt struct to describe logged sign in attempt.
+type SignInAttempt struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    *uuid.UUID `json:"user_id" db:"user_id"`
+	Email     string     `json:"email" db:"email"`
+	IP        string     `json:"ip" db:"ip"`
+	UserAgent string     `json:"user_agent" db:"user_agent"`
+	Outcome   string     `json:"outcome" db:"outcome"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SignInThrottle struct to describe failed sign in attempts of an account or a client IP.
+type SignInThrottle struct {
+	Key          string     `json:"key" db:"key"`
+	Failures     int        `json:"failures" db:"failures"`
+	BlockedUntil *time.Time `json:"blocked_until" db:"blocked_until"`
+	Locked       bool       `json:"locked" db:"locked"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type UnlockUserInput struct {
+	ID uuid.UUID `json:"id" validate:"required,uuid"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RefreshToken struct to describe issued refresh token.
+// Every rotated token stays in the same family, so a reuse of an
+// already rotated token can revoke all of its descendants.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id" db:"family_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	ReplacedBy *uuid.UUID `json:"replaced_by" db:"replaced_by"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type SignOutInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -5,25 +5,50 @@ import (
 	"time"
 )
 
+// User struct to describe stored user, it is never returned by API as is, see app/dto.
 type User struct {
-	ID        uuid.UUID `json:"id" db:"id" validate:"required,uuid"`
-	Name      string    `json:"name" db:"name" validate:"lte=30"`
-	Email     string    `json:"email" db:"email" validate:"required,email"`
-	Password  string    `json:"password" db:"password" validate:"required,min=3,max=30"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID         uuid.UUID  `json:"id" db:"id" validate:"required,uuid"`
+	Name       string     `json:"name" db:"name" validate:"lte=30"`
+	Email      string     `json:"email" db:"email" validate:"required,email"`
+	Password   string     `json:"-" db:"password" validate:"required"`
+	Role       string     `json:"role" db:"role" validate:"omitempty,oneof=admin agent owner viewer"`
+	VerifiedAt *time.Time `json:"verified_at" db:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 }
 
 type UserCreateInput struct {
 	Name     string `json:"name" db:"name" validate:"lte=30"`
 	Email    string `json:"email" db:"email" validate:"required,email"`
-	Password string `json:"password" db:"password" validate:"required,min=3,max=30"`
+	Password string `json:"password" db:"password" validate:"required"`
+	Role     string `json:"role" db:"role" validate:"omitempty,oneof=admin agent owner viewer"`
 }
 
 type UserUpdateInput struct {
-	ID       uuid.UUID `json:"id" db:"id" validate:"required,uuid"`
-	Name     string    `json:"name" db:"name" validate:"lte=30"`
-	Email    string    `json:"email" db:"email" validate:"email"`
-	Password string    `json:"password" db:"password" validate:"lte=30"`
+	ID   uuid.UUID `json:"id" db:"id" validate:"required,uuid"`
+	Name string    `json:"name" db:"name" validate:"lte=30"`
+	Role string    `json:"role" db:"role" validate:"omitempty,oneof=admin agent owner viewer"`
+}
+
+type UpdateMeInput struct {
+	Name *string `json:"name" validate:"omitempty,lte=30"`
+}
+
+type DeleteMeInput struct {
+	CurrentPassword string `json:"current_password" validate:"required,lte=1024"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required,lte=1024"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type ChangeEmailInput struct {
+	CurrentPassword string `json:"current_password" validate:"required,lte=1024"`
+	Email           string `json:"email" validate:"required,email,lte=255"`
+}
+
+type ConfirmEmailChangeInput struct {
+	Token string `json:"token" validate:"required"`
 }
 
 type UserDeleteInput struct {
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// EmailVerificationToken struct to describe issued email verification token.
+// The token itself is signed, only its ID is stored to make it single-use.
+type EmailVerificationToken struct {
+	ID        uuid.UUID  `json:"id" db:"jti"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Email     string     `json:"email" db:"email"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,98 @@
+package queries
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+)
+
+// APIKeyQueries struct for queries from APIKey model.
+type APIKeyQueries struct {
+	*sqlx.DB
+}
+
+// CreateAPIKey method for creating API key by given APIKey object.
+func (q *APIKeyQueries) CreateAPIKey(ctx context.Context, k *models.APIKey) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := q.ExecContext(ctx, query, k.ID, k.UserID, k.Name, k.Prefix, k.KeyHash, k.Scopes, k.ExpiresAt, k.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKeys method for getting all API keys by given user ID.
+func (q *APIKeyQueries) GetAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	keys := []models.APIKey{}
+
+	query := `SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	err := q.SelectContext(ctx, &keys, query, userID)
+	if err != nil {
+		return keys, err
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByHash method for getting one API key by given key hash.
+func (q *APIKeyQueries) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	key := models.APIKey{}
+
+	query := `SELECT * FROM api_keys WHERE key_hash = $1`
+
+	err := q.GetContext(ctx, &key, query, hash)
+	if err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+// TouchAPIKey method for saving the last usage time of API key by given ID.
+func (q *APIKeyQueries) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE api_keys SET last_used_at = now() WHERE id = $1`
+
+	_, err := q.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAPIKey method for revoking API key by given ID and owner ID.
+// It reports false, if the key is not found or was already revoked.
+func (q *APIKeyQueries) RevokeAPIKey(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := q.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,28 @@
+package queries
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+)
+
+// AuditQueries struct for queries from ImpersonationAuditEntry model.
+type AuditQueries struct {
+	*sqlx.DB
+}
+
+// CreateImpersonationAuditEntry method for saving audit entry by given ImpersonationAuditEntry object.
+func (q *AuditQueries) CreateImpersonationAuditEntry(ctx context.Context, e *models.ImpersonationAuditEntry) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO impersonation_audit_log (id, actor_id, user_id, token_id, action, method, path, ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := q.ExecContext(ctx, query, e.ID, e.ActorID, e.UserID, e.TokenID, e.Action, e.Method, e.Path, e.IP, e.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,24 +1,28 @@
 package queries
 
 import (
+	"context"
 	"fmt"
 	"github.com/google/uuid"
-	"github.com/jmoiron/sqlx"
 	"github.com/popeskul/houser/app/models"
 )
 
 // AuthQueries struct for queries from User model.
 type AuthQueries struct {
-	*sqlx.DB
+	Executor
 }
 
-// Login method for getting one user by given email and password.
-func (q *AuthQueries) Login(email, password string) (models.User, error) {
+// Login method for getting one user with the password hash by given email.
+// The password itself is verified by the caller.
+func (q *AuthQueries) Login(ctx context.Context, email string) (models.User, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
 	user := models.User{}
 
-	query := `SELECT id FROM users WHERE email = $1 AND password = $2`
+	query := `SELECT * FROM users WHERE email = $1`
 
-	err := q.Get(&user, query, email, password)
+	err := q.GetContext(ctx, &user, query, email)
 	if err != nil {
 		return user, err
 	}
@@ -26,13 +30,48 @@ func (q *AuthQueries) Login(email, password string) (models.User, error) {
 	return user, nil
 }
 
+// UpdatePasswordHash method for replacing stored password hash by given user ID.
+func (q *AuthQueries) UpdatePasswordHash(ctx context.Context, id uuid.UUID, hash string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET password = $2 WHERE id = $1`
+
+	_, err := q.ExecContext(ctx, query, id, hash)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountPasswordsWithPrefix method for counting users, whose stored password starts with given prefix.
+func (q *AuthQueries) CountPasswordsWithPrefix(ctx context.Context, prefix string) (int, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	var count int
+
+	query := `SELECT count(*) FROM users WHERE left(password, length($1)) = $1`
+
+	err := q.GetContext(ctx, &count, query, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
 // RegisterUser method for creating user by given User object.
-func (q *AuthQueries) RegisterUser(b *models.User) (*uuid.UUID, error) {
+func (q *AuthQueries) RegisterUser(ctx context.Context, b *models.User) (*uuid.UUID, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
 	var id *uuid.UUID
 
-	query := `INSERT INTO users VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	query := `INSERT INTO users (id, name, email, password, role, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
 
-	row := q.QueryRow(query, b.ID, b.Name, b.Email, b.Password, b.CreatedAt)
+	row := q.QueryRowContext(ctx, query, b.ID, b.Name, b.Email, b.Password, b.Role, b.CreatedAt)
 
 	err := row.Scan(&id)
 	if err != nil {
@@ -0,0 +1,73 @@
+package queries
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// interruptionKey is used to keep interruption of queries in context.
+type interruptionKey struct{}
+
+// interruption struct to describe the first canceled or timed out query of the context.
+type interruption struct {
+	mu  sync.Mutex
+	err error
+}
+
+// TrackInterruptions func returns context, which remembers, if any of its
+// queries was canceled or ran out of time, see Interruption.
+func TrackInterruptions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, interruptionKey{}, &interruption{})
+}
+
+// Interruption func returns context.DeadlineExceeded or context.Canceled,
+// if a query of the tracked context was interrupted, or nil otherwise.
+func Interruption(ctx context.Context) error {
+	i, ok := ctx.Value(interruptionKey{}).(*interruption)
+	if !ok {
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.err
+}
+
+// readContext func limits context of reading query with timeout from config.
+func readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, configs.Default().DB.ReadTimeout)
+}
+
+// writeContext func limits context of writing query with timeout from config.
+func writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, configs.Default().DB.WriteTimeout)
+}
+
+// queryContext func limits context of query with the given timeout, zero means no timeout.
+// The returned cancel func remembers interruption of the query in the tracked context.
+func queryContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	return ctx, func() {
+		if err := ctx.Err(); err != nil {
+			if i, ok := ctx.Value(interruptionKey{}).(*interruption); ok {
+				i.mu.Lock()
+				if i.err == nil {
+					i.err = err
+				}
+				i.mu.Unlock()
+			}
+		}
+
+		cancel()
+	}
+}
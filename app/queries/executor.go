@@ -0,0 +1,17 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Executor interface to describe connection pool or transaction, which runs queries.
+// Both *sqlx.DB and *sqlx.Tx implement it.
+type Executor interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
@@ -1,22 +1,25 @@
 package queries
 
 import (
+	"context"
 	"fmt"
 	"github.com/google/uuid"
-	"github.com/jmoiron/sqlx"
 	"github.com/popeskul/houser/app/models"
 )
 
 // HouseQueries struct for queries from User model.
 type HouseQueries struct {
-	*sqlx.DB
+	Executor
 }
 
 // CreateHouse method for creating user by given User object.
-func (q *HouseQueries) CreateHouse(h *models.House) error {
+func (q *HouseQueries) CreateHouse(ctx context.Context, h *models.House) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
 	query := `INSERT INTO houses VALUES ($1, $2, $3, $4, $5)`
 
-	_, err := q.Exec(query, h.ID, h.Description, h.Address, h.OwnerID, h.CreatedAt)
+	_, err := q.ExecContext(ctx, query, h.ID, h.Description, h.Address, h.OwnerID, h.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -24,13 +27,33 @@ func (q *HouseQueries) CreateHouse(h *models.House) error {
 	return nil
 }
 
+// GetHousesByOwner method for getting all houses by given owner ID.
+func (q *HouseQueries) GetHousesByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.House, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	houses := []models.House{}
+
+	query := `SELECT * FROM houses WHERE owner_id = $1 ORDER BY created_at DESC`
+
+	err := q.SelectContext(ctx, &houses, query, ownerID)
+	if err != nil {
+		return houses, err
+	}
+
+	return houses, nil
+}
+
 // GetHouses method for getting all users.
-func (q *HouseQueries) GetHouses() ([]models.House, error) {
+func (q *HouseQueries) GetHouses(ctx context.Context) ([]models.House, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
 	var houses []models.House
 
 	query := `SELECT * FROM houses`
 
-	err := q.Select(&houses, query)
+	err := q.SelectContext(ctx, &houses, query)
 	if err != nil {
 		return houses, err
 	}
@@ -39,12 +62,33 @@ func (q *HouseQueries) GetHouses() ([]models.House, error) {
 }
 
 // GetHouseById method for getting one user by given ID.
-func (q *UserQueries) GetHouseById(id uuid.UUID) (models.House, error) {
+func (q *HouseQueries) GetHouseById(ctx context.Context, id uuid.UUID) (models.House, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
 	house := models.House{}
 
 	query := `SELECT * FROM houses WHERE id = $1`
 
-	err := q.Get(&house, query, id)
+	err := q.GetContext(ctx, &house, query, id)
+	if err != nil {
+		return house, err
+	}
+
+	return house, nil
+}
+
+// GetHouseByIdForUpdate method for getting one house by given ID and locking it
+// until the end of transaction, so nobody changes it between check and write.
+func (q *HouseQueries) GetHouseByIdForUpdate(ctx context.Context, id uuid.UUID) (models.House, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	house := models.House{}
+
+	query := `SELECT * FROM houses WHERE id = $1 FOR UPDATE`
+
+	err := q.GetContext(ctx, &house, query, id)
 	if err != nil {
 		return house, err
 	}
@@ -53,11 +97,14 @@ func (q *UserQueries) GetHouseById(id uuid.UUID) (models.House, error) {
 }
 
 // UpdateHouseById method for updating house by given House object.
-func (q *HouseQueries) UpdateHouseById(id uuid.UUID, house *models.House) error {
+func (q *HouseQueries) UpdateHouseById(ctx context.Context, id uuid.UUID, house *models.House) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
 	query := `UPDATE houses SET description = $2, address = $3 WHERE id = $1`
 	fmt.Println(id, house)
 
-	_, err := q.Exec(query, id, house.Description, house.Address)
+	_, err := q.ExecContext(ctx, query, id, house.Description, house.Address)
 	if err != nil {
 		return err
 	}
@@ -66,10 +113,13 @@ func (q *HouseQueries) UpdateHouseById(id uuid.UUID, house *models.House) error
 }
 
 // DeleteHouseByID method for delete user by given ID.
-func (q *UserQueries) DeleteHouseByID(id uuid.UUID) error {
+func (q *HouseQueries) DeleteHouseByID(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
 	query := `DELETE FROM houses WHERE id = $1`
 
-	_, err := q.Exec(query, id)
+	_, err := q.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,44 @@
+package queries
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+)
+
+// IdentityQueries struct for queries from UserIdentity model.
+type IdentityQueries struct {
+	*sqlx.DB
+}
+
+// GetUserIdentity method for getting external identity by given provider and subject.
+func (q *IdentityQueries) GetUserIdentity(ctx context.Context, provider, subject string) (models.UserIdentity, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	identity := models.UserIdentity{}
+
+	query := `SELECT * FROM user_identities WHERE provider = $1 AND subject = $2`
+
+	err := q.GetContext(ctx, &identity, query, provider, subject)
+	if err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+// CreateUserIdentity method for linking external identity by given UserIdentity object.
+func (q *IdentityQueries) CreateUserIdentity(ctx context.Context, i *models.UserIdentity) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO user_identities (id, user_id, provider, subject, email, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := q.ExecContext(ctx, query, i.ID, i.UserID, i.Provider, i.Subject, i.Email, i.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
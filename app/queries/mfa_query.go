@@ -0,0 +1,145 @@
+package queries
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+)
+
+// MFAQueries struct for queries from UserMFA and MFARecoveryCode models.
+type MFAQueries struct {
+	*sqlx.DB
+}
+
+// GetUserMFA method for getting second factor by given user ID.
+func (q *MFAQueries) GetUserMFA(ctx context.Context, userID uuid.UUID) (models.UserMFA, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	mfa := models.UserMFA{}
+
+	query := `SELECT * FROM user_mfa WHERE user_id = $1`
+
+	err := q.GetContext(ctx, &mfa, query, userID)
+	if err != nil {
+		return mfa, err
+	}
+
+	return mfa, nil
+}
+
+// StartUserMFA method for saving a new secret of not yet enabled second factor.
+// It reports false, if the second factor is already enabled.
+func (q *MFAQueries) StartUserMFA(ctx context.Context, mfa *models.UserMFA) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO user_mfa (user_id, secret, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+		WHERE user_mfa.enabled_at IS NULL`
+
+	result, err := q.ExecContext(ctx, query, mfa.UserID, mfa.Secret, mfa.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// EnableUserMFA method for enabling second factor and saving its recovery code hashes.
+func (q *MFAQueries) EnableUserMFA(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	tx, err := q.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE user_mfa SET enabled_at = now() WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseMFAStep method for saving the time step of accepted TOTP code.
+// It reports false, if a code of the same or later step was already used.
+func (q *MFAQueries) UseMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	result, err := q.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// UseMFARecoveryCode method for marking recovery code as used by given user ID and code hash.
+// It reports false, if the code is unknown or was already used.
+func (q *MFAQueries) UseMFARecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE mfa_recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := q.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// DeleteUserMFA method for removing second factor and recovery codes by given user ID.
+func (q *MFAQueries) DeleteUserMFA(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	tx, err := q.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,115 @@
+package queries
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+)
+
+// PasswordQueries struct for queries from PasswordResetToken model.
+type PasswordQueries struct {
+	*sqlx.DB
+}
+
+// CreatePasswordResetToken method for creating reset token by given PasswordResetToken object.
+func (q *PasswordQueries) CreatePasswordResetToken(ctx context.Context, t *models.PasswordResetToken) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := q.ExecContext(ctx, query, t.ID, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPasswordResetTokenByHash method for getting one reset token by given token hash.
+func (q *PasswordQueries) GetPasswordResetTokenByHash(ctx context.Context, hash string) (models.PasswordResetToken, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	token := models.PasswordResetToken{}
+
+	query := `SELECT * FROM password_reset_tokens WHERE token_hash = $1`
+
+	err := q.GetContext(ctx, &token, query, hash)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// GetLatestPasswordResetToken method for getting the last reset token by given user ID.
+func (q *PasswordQueries) GetLatestPasswordResetToken(ctx context.Context, userID uuid.UUID) (models.PasswordResetToken, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	token := models.PasswordResetToken{}
+
+	query := `SELECT * FROM password_reset_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+	err := q.GetContext(ctx, &token, query, userID)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// GetLatestPasswordResetTokenByEmail method for getting the last reset token by given user email.
+func (q *PasswordQueries) GetLatestPasswordResetTokenByEmail(ctx context.Context, email string) (models.PasswordResetToken, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	token := models.PasswordResetToken{}
+
+	query := `SELECT t.* FROM password_reset_tokens t JOIN users u ON u.id = t.user_id WHERE u.email = $1 ORDER BY t.created_at DESC LIMIT 1`
+
+	err := q.GetContext(ctx, &token, query, email)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// UsePasswordResetToken method for marking reset token as used by given token ID.
+// It reports false, if the token was already used.
+func (q *PasswordQueries) UsePasswordResetToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE password_reset_tokens SET used_at = now() WHERE id = $1 AND used_at IS NULL`
+
+	result, err := q.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// UseUserPasswordResetTokens method for marking all outstanding reset tokens as used by given user ID.
+func (q *PasswordQueries) UseUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE password_reset_tokens SET used_at = now() WHERE user_id = $1 AND used_at IS NULL`
+
+	_, err := q.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
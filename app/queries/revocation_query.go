@@ -0,0 +1,73 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// RevocationQueries struct for queries of revoked access tokens.
+type RevocationQueries struct {
+	*sqlx.DB
+}
+
+// RevokeToken method for revoking one access token by given token ID.
+func (q *RevocationQueries) RevokeToken(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`
+
+	_, err := q.ExecContext(ctx, query, jti, userID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserTokens method for revoking all access tokens of user issued up to given time,
+// except the kept one. The kept token follows the latest revocation.
+func (q *RevocationQueries) RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, keptID uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO revoked_user_tokens (user_id, revoked_before, kept_token_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			kept_token_id = CASE WHEN EXCLUDED.revoked_before >= revoked_user_tokens.revoked_before
+				THEN EXCLUDED.kept_token_id ELSE revoked_user_tokens.kept_token_id END,
+			revoked_before = GREATEST(revoked_user_tokens.revoked_before, EXCLUDED.revoked_before)`
+
+	_, err := q.ExecContext(ctx, query, userID, before, keptID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTokenRevocation method for checking, if access token was revoked by itself,
+// and getting the time up to which all tokens of its user are revoked with the kept token.
+func (q *RevocationQueries) GetTokenRevocation(ctx context.Context, jti, userID uuid.UUID) (bool, time.Time, uuid.UUID, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	var revoked bool
+	var revokedBefore sql.NullTime
+	var keptID uuid.NullUUID
+
+	query := `SELECT
+		EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1),
+		(SELECT revoked_before FROM revoked_user_tokens WHERE user_id = $2),
+		(SELECT kept_token_id FROM revoked_user_tokens WHERE user_id = $2)`
+
+	err := q.QueryRowContext(ctx, query, jti, userID).Scan(&revoked, &revokedBefore, &keptID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, time.Time{}, uuid.Nil, err
+	}
+
+	return revoked, revokedBefore.Time, keptID.UUID, nil
+}
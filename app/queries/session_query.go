@@ -0,0 +1,127 @@
+package queries
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+	"time"
+)
+
+// SessionQueries struct for queries from Session model.
+type SessionQueries struct {
+	*sqlx.DB
+}
+
+// CreateSession method for creating session by given Session object.
+func (q *SessionQueries) CreateSession(ctx context.Context, s *models.Session) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO sessions (id, user_id, device, ip, user_agent, created_at, last_seen_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := q.ExecContext(ctx, query, s.ID, s.UserID, s.Device, s.IP, s.UserAgent, s.CreatedAt, s.LastSeenAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSession method for getting one session by given ID.
+func (q *SessionQueries) GetSession(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	session := models.Session{}
+
+	query := `SELECT * FROM sessions WHERE id = $1`
+
+	err := q.GetContext(ctx, &session, query, id)
+	if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+// GetActiveSessions method for getting not revoked sessions by given user ID,
+// which were seen after the given time.
+func (q *SessionQueries) GetActiveSessions(ctx context.Context, userID uuid.UUID, seenAfter time.Time) ([]models.Session, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	sessions := []models.Session{}
+
+	query := `SELECT * FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND last_seen_at > $2 ORDER BY last_seen_at DESC`
+
+	err := q.SelectContext(ctx, &sessions, query, userID, seenAfter)
+	if err != nil {
+		return sessions, err
+	}
+
+	return sessions, nil
+}
+
+// TouchSession method for saving the last activity time and IP of session by given ID.
+func (q *SessionQueries) TouchSession(ctx context.Context, id uuid.UUID, ip string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET last_seen_at = now(), ip = $2 WHERE id = $1`
+
+	_, err := q.ExecContext(ctx, query, id, ip)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeSession method for revoking session by given ID.
+func (q *SessionQueries) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+
+	_, err := q.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserSessions method for revoking all sessions by given user ID.
+func (q *SessionQueries) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := q.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeOtherUserSessions method for revoking all sessions of the user except the kept one.
+// It returns IDs of revoked sessions.
+func (q *SessionQueries) RevokeOtherUserSessions(ctx context.Context, userID, keepID uuid.UUID) ([]uuid.UUID, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	ids := []uuid.UUID{}
+
+	query := `UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL RETURNING id`
+
+	err := q.SelectContext(ctx, &ids, query, userID, keepID)
+	if err != nil {
+		return ids, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,160 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/popeskul/houser/app/models"
+	"time"
+)
+
+// ErrSignInThrottleBusy is returned, if the throttle is locked by another sign in attempt.
+var ErrSignInThrottleBusy = errors.New("another sign in attempt is in progress")
+
+// SignInQueries struct for queries from SignInAttempt and SignInThrottle models.
+type SignInQueries struct {
+	Executor
+}
+
+// CreateSignInAttempt method for logging sign in attempt by given SignInAttempt object.
+func (q *SignInQueries) CreateSignInAttempt(ctx context.Context, a *models.SignInAttempt) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO sign_in_attempts (id, user_id, email, ip, user_agent, outcome, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := q.ExecContext(ctx, query, a.ID, a.UserID, a.Email, a.IP, a.UserAgent, a.Outcome, a.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSignInAttempts method for getting the last sign in attempts by given user ID.
+func (q *SignInQueries) GetSignInAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]models.SignInAttempt, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	attempts := []models.SignInAttempt{}
+
+	query := `SELECT * FROM sign_in_attempts WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	err := q.SelectContext(ctx, &attempts, query, userID, limit)
+	if err != nil {
+		return attempts, err
+	}
+
+	return attempts, nil
+}
+
+// GetSignInThrottle method for getting failures of one account or client IP by given key.
+func (q *SignInQueries) GetSignInThrottle(ctx context.Context, key string) (models.SignInThrottle, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	throttle := models.SignInThrottle{}
+
+	query := `SELECT * FROM sign_in_throttles WHERE key = $1`
+
+	err := q.GetContext(ctx, &throttle, query, key)
+	if err != nil {
+		return throttle, err
+	}
+
+	return throttle, nil
+}
+
+// CreateSignInThrottle method for creating throttle without failures by given key, if it is missing.
+func (q *SignInQueries) CreateSignInThrottle(ctx context.Context, key string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO sign_in_throttles (key, updated_at) VALUES ($1, now()) ON CONFLICT (key) DO NOTHING`
+
+	_, err := q.ExecContext(ctx, query, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LockSignInThrottle method for getting throttle by given key and locking it until the end of transaction.
+// It does not wait for the lock of another transaction, ErrSignInThrottleBusy is returned instead.
+func (q *SignInQueries) LockSignInThrottle(ctx context.Context, key string) (models.SignInThrottle, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	throttle := models.SignInThrottle{}
+
+	query := `SELECT * FROM sign_in_throttles WHERE key = $1 FOR UPDATE NOWAIT`
+
+	err := q.GetContext(ctx, &throttle, query, key)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "55P03" { // lock_not_available
+		return throttle, ErrSignInThrottleBusy
+	}
+	if err != nil {
+		return throttle, err
+	}
+
+	return throttle, nil
+}
+
+// RecordSignInFailure method for counting one more failure by given key.
+// Failures are counted from scratch, if the last one is older than the window
+// or the lockout is over, so one failure after lockout does not lock again.
+func (q *SignInQueries) RecordSignInFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO sign_in_throttles (key, failures, updated_at) VALUES ($1, 1, now())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN sign_in_throttles.locked AND sign_in_throttles.blocked_until <= now() THEN 1
+				WHEN sign_in_throttles.updated_at < now() - make_interval(secs => $2) AND NOT sign_in_throttles.locked THEN 1
+				ELSE sign_in_throttles.failures + 1 END,
+			locked = sign_in_throttles.locked AND COALESCE(sign_in_throttles.blocked_until > now(), true),
+			updated_at = now()
+		RETURNING failures`
+
+	var failures int
+	err := q.GetContext(ctx, &failures, query, key, window.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+// BlockSignIn method for blocking sign in by given key until the given time.
+func (q *SignInQueries) BlockSignIn(ctx context.Context, key string, until time.Time, locked bool) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE sign_in_throttles SET blocked_until = $2, locked = $3 WHERE key = $1`
+
+	_, err := q.ExecContext(ctx, query, key, until, locked)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetSignInThrottle method for forgetting failures by given key.
+func (q *SignInQueries) ResetSignInThrottle(ctx context.Context, key string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `DELETE FROM sign_in_throttles WHERE key = $1`
+
+	_, err := q.ExecContext(ctx, query, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package queries
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+)
+
+// TokenQueries struct for queries from RefreshToken model.
+type TokenQueries struct {
+	*sqlx.DB
+}
+
+// CreateRefreshToken method for creating refresh token by given RefreshToken object.
+func (q *TokenQueries) CreateRefreshToken(ctx context.Context, t *models.RefreshToken) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := q.ExecContext(ctx, query, t.ID, t.UserID, t.FamilyID, t.TokenHash, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetRefreshTokenByHash method for getting one refresh token by given token hash.
+func (q *TokenQueries) GetRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	token := models.RefreshToken{}
+
+	query := `SELECT * FROM refresh_tokens WHERE token_hash = $1`
+
+	err := q.GetContext(ctx, &token, query, hash)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken method for marking refresh token as replaced by given token ID.
+// It reports false, if the token was already revoked or rotated by someone else.
+func (q *TokenQueries) RotateRefreshToken(ctx context.Context, id, replacedBy uuid.UUID) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = now(), replaced_by = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := q.ExecContext(ctx, query, id, replacedBy)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// RevokeRefreshTokenFamily method for revoking all refresh tokens by given family ID.
+func (q *TokenQueries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	_, err := q.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens method for revoking all refresh tokens by given user ID.
+func (q *TokenQueries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := q.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeOtherUserRefreshTokens method for revoking all refresh tokens of the user except the kept family.
+func (q *TokenQueries) RevokeOtherUserRefreshTokens(ctx context.Context, userID, keepFamilyID uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL`
+
+	_, err := q.ExecContext(ctx, query, userID, keepFamilyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,23 +1,26 @@
 package queries
 
 import (
+	"context"
 	"github.com/google/uuid"
-	"github.com/jmoiron/sqlx"
 	"github.com/popeskul/houser/app/models"
 )
 
 // UserQueries struct for queries from User model.
 type UserQueries struct {
-	*sqlx.DB
+	Executor
 }
 
 // GetUsers method for getting all users.
-func (q *UserQueries) GetUsers() ([]models.User, error) {
+func (q *UserQueries) GetUsers(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
 	var users []models.User
 
 	query := `SELECT * FROM users`
 
-	err := q.Select(&users, query)
+	err := q.SelectContext(ctx, &users, query)
 	if err != nil {
 		return users, err
 	}
@@ -26,12 +29,15 @@ func (q *UserQueries) GetUsers() ([]models.User, error) {
 }
 
 // GetUserById method for getting one user by given ID.
-func (q *UserQueries) GetUserById(id uuid.UUID) (models.User, error) {
+func (q *UserQueries) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
 	user := models.User{}
 
 	query := `SELECT * FROM users WHERE id = $1`
 
-	err := q.Get(&user, query, id)
+	err := q.GetContext(ctx, &user, query, id)
 	if err != nil {
 		return user, err
 	}
@@ -40,10 +46,13 @@ func (q *UserQueries) GetUserById(id uuid.UUID) (models.User, error) {
 }
 
 // CreateUser method for creating user by given User object.
-func (q *UserQueries) CreateUser(b *models.User) error {
-	query := `INSERT INTO users VALUES ($1, $2, $3, $4, $5)`
+func (q *UserQueries) CreateUser(ctx context.Context, b *models.User) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO users (id, name, email, password, role, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
 
-	_, err := q.Exec(query, b.ID, b.Name, b.Email, b.Password, b.CreatedAt)
+	_, err := q.ExecContext(ctx, query, b.ID, b.Name, b.Email, b.Password, b.Role, b.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -51,11 +60,15 @@ func (q *UserQueries) CreateUser(b *models.User) error {
 	return nil
 }
 
-// UpdateUser method for updating user by given User object.
-func (q *UserQueries) UpdateUser(id uuid.UUID, user *models.User) error {
-	query := `UPDATE users SET name = $2, email = $3, password = $4 WHERE id = $1`
+// UpdateUser method for updating profile of user by given User object.
+// Email and password are changed by the user only, see ChangeUserEmail and UpdatePasswordHash.
+func (q *UserQueries) UpdateUser(ctx context.Context, id uuid.UUID, user *models.User) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
 
-	_, err := q.Exec(query, id, user.Name, user.Email, user.Password)
+	query := `UPDATE users SET name = $2, role = $3 WHERE id = $1`
+
+	_, err := q.ExecContext(ctx, query, id, user.Name, user.Role)
 	if err != nil {
 		return err
 	}
@@ -64,10 +77,13 @@ func (q *UserQueries) UpdateUser(id uuid.UUID, user *models.User) error {
 }
 
 // DeleteUser method for delete user by given ID.
-func (q *UserQueries) DeleteUser(id uuid.UUID) error {
+func (q *UserQueries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
 	query := `DELETE FROM users WHERE id = $1`
 
-	_, err := q.Exec(query, id)
+	_, err := q.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
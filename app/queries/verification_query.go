@@ -0,0 +1,120 @@
+package queries
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/models"
+)
+
+// VerificationQueries struct for queries from EmailVerificationToken model.
+type VerificationQueries struct {
+	*sqlx.DB
+}
+
+// CreateEmailVerificationToken method for creating verification token by given object.
+func (q *VerificationQueries) CreateEmailVerificationToken(ctx context.Context, t *models.EmailVerificationToken) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO email_verification_tokens (jti, user_id, email, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := q.ExecContext(ctx, query, t.ID, t.UserID, t.Email, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetEmailVerificationToken method for getting one verification token by given token ID.
+func (q *VerificationQueries) GetEmailVerificationToken(ctx context.Context, id uuid.UUID) (models.EmailVerificationToken, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	token := models.EmailVerificationToken{}
+
+	query := `SELECT * FROM email_verification_tokens WHERE jti = $1`
+
+	err := q.GetContext(ctx, &token, query, id)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// GetLatestEmailVerificationToken method for getting the last verification token by given user ID.
+func (q *VerificationQueries) GetLatestEmailVerificationToken(ctx context.Context, userID uuid.UUID) (models.EmailVerificationToken, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	token := models.EmailVerificationToken{}
+
+	query := `SELECT * FROM email_verification_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+	err := q.GetContext(ctx, &token, query, userID)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// UseEmailVerificationToken method for marking verification token as used by given token ID.
+// It reports false, if the token was already used.
+func (q *VerificationQueries) UseEmailVerificationToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE email_verification_tokens SET used_at = now() WHERE jti = $1 AND used_at IS NULL`
+
+	result, err := q.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// VerifyUserEmail method for marking email of user as verified by given user ID and email.
+// It reports false, if the user changed email after the token was issued.
+func (q *VerificationQueries) VerifyUserEmail(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET verified_at = now() WHERE id = $1 AND email = $2`
+
+	result, err := q.ExecContext(ctx, query, userID, email)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// ChangeUserEmail method for replacing email of user by given user ID with the confirmed one.
+// The new email is verified by the confirmation itself.
+func (q *VerificationQueries) ChangeUserEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET email = $2, verified_at = now() WHERE id = $1`
+
+	_, err := q.ExecContext(ctx, query, userID, email)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
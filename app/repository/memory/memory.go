@@ -0,0 +1,646 @@
+// Package memory keeps users, houses and API keys in memory, it is meant for tests.
+// It follows the same rules as PostgreSQL tables, e.g. unique emails and
+// existing owners of houses, so handlers behave the same with both storages.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/app/repository"
+	"github.com/popeskul/houser/pkg/rbac"
+)
+
+var (
+	// ErrDuplicateID is returned, if a row with the same ID exists.
+	ErrDuplicateID = errors.New("memory: duplicate id")
+	// ErrDuplicateEmail is returned, if a user with the same email exists.
+	ErrDuplicateEmail = errors.New("memory: duplicate email")
+	// ErrInvalidRole is returned, if role of the user is not known.
+	ErrInvalidRole = errors.New("memory: invalid role")
+	// ErrUnknownOwner is returned, if owner of the house does not exist.
+	ErrUnknownOwner = errors.New("memory: unknown owner")
+	// ErrOwnerHasHouses is returned on deleting user, who still owns houses.
+	ErrOwnerHasHouses = errors.New("memory: user owns houses")
+	// ErrDuplicateKeyHash is returned, if an API key with the same hash exists.
+	ErrDuplicateKeyHash = errors.New("memory: duplicate key hash")
+)
+
+// Store struct to describe users, houses and API keys in memory.
+// It implements repository.UserRepository, repository.HouseRepository,
+// repository.AuthRepository and repository.APIKeyRepository and is safe for concurrent use.
+// Like queries to PostgreSQL, calls with done context fail with its error.
+// It implements repository.Transactor too.
+type Store struct {
+	txMu   sync.Mutex // serializes transactions, see InTx
+	mu     sync.RWMutex
+	users  map[uuid.UUID]models.User
+	houses map[uuid.UUID]models.House
+	keys   map[uuid.UUID]models.APIKey
+}
+
+// NewStore func for create a new empty memory store.
+func NewStore() *Store {
+	return &Store{
+		users:  map[uuid.UUID]models.User{},
+		houses: map[uuid.UUID]models.House{},
+		keys:   map[uuid.UUID]models.APIKey{},
+	}
+}
+
+// GetUsers method for getting all users.
+func (s *Store) GetUsers(ctx context.Context) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+
+	// Keep order stable, map iteration is random.
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	return users, nil
+}
+
+// GetUserById method for getting one user by given ID.
+func (s *Store) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+// CreateUser method for creating user by given User object.
+func (s *Store) CreateUser(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insertUser(user)
+}
+
+// UpdateUser method for updating profile of user by given User object.
+// Like in PostgreSQL, only name and role are changed.
+func (s *Store) UpdateUser(ctx context.Context, id uuid.UUID, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(id, user)
+}
+
+// DeleteUser method for delete user by given ID.
+func (s *Store) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deleteUser(id)
+}
+
+// Login method for getting one user with the password hash by given email.
+func (s *Store) Login(ctx context.Context, email string) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return models.User{}, sql.ErrNoRows
+}
+
+// UpdatePasswordHash method for replacing stored password hash by given user ID.
+func (s *Store) UpdatePasswordHash(ctx context.Context, id uuid.UUID, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updatePasswordHash(id, hash)
+
+	return nil
+}
+
+// RegisterUser method for creating user by given User object.
+func (s *Store) RegisterUser(ctx context.Context, user *models.User) (*uuid.UUID, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.insertUser(user); err != nil {
+		return nil, err
+	}
+
+	id := user.ID
+
+	return &id, nil
+}
+
+// GetHouses method for getting all houses.
+func (s *Store) GetHouses(ctx context.Context) ([]models.House, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	houses := make([]models.House, 0, len(s.houses))
+	for _, house := range s.houses {
+		houses = append(houses, house)
+	}
+
+	// Keep order stable, map iteration is random.
+	sort.Slice(houses, func(i, j int) bool {
+		return houses[i].CreatedAt.Before(houses[j].CreatedAt)
+	})
+
+	return houses, nil
+}
+
+// GetHousesByOwner method for getting all houses by given owner ID, newest first.
+func (s *Store) GetHousesByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.House, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	houses := []models.House{}
+	for _, house := range s.houses {
+		if house.OwnerID == ownerID {
+			houses = append(houses, house)
+		}
+	}
+
+	sort.Slice(houses, func(i, j int) bool {
+		return houses[i].CreatedAt.After(houses[j].CreatedAt)
+	})
+
+	return houses, nil
+}
+
+// GetHouseById method for getting one house by given ID.
+func (s *Store) GetHouseById(ctx context.Context, id uuid.UUID) (models.House, error) {
+	if err := ctx.Err(); err != nil {
+		return models.House{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	house, ok := s.houses[id]
+	if !ok {
+		return models.House{}, sql.ErrNoRows
+	}
+
+	return house, nil
+}
+
+// GetHouseByIdForUpdate method for getting one house by given ID in transaction.
+// Transactions are serialized by InTx, so there is nothing to lock here.
+func (s *Store) GetHouseByIdForUpdate(ctx context.Context, id uuid.UUID) (models.House, error) {
+	return s.GetHouseById(ctx, id)
+}
+
+// CreateHouse method for creating house by given House object.
+func (s *Store) CreateHouse(ctx context.Context, house *models.House) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insertHouse(house)
+}
+
+// UpdateHouseById method for updating house by given House object.
+// Like in PostgreSQL, only description and address are changed.
+func (s *Store) UpdateHouseById(ctx context.Context, id uuid.UUID, house *models.House) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateHouse(id, house)
+
+	return nil
+}
+
+// DeleteHouseByID method for delete house by given ID.
+func (s *Store) DeleteHouseByID(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.houses, id)
+
+	return nil
+}
+
+// CreateAPIKey method for creating API key by given APIKey object.
+func (s *Store) CreateAPIKey(ctx context.Context, k *models.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[k.ID]; ok {
+		return ErrDuplicateID
+	}
+
+	for _, stored := range s.keys {
+		if stored.KeyHash == k.KeyHash {
+			return ErrDuplicateKeyHash
+		}
+	}
+
+	s.keys[k.ID] = *k
+
+	return nil
+}
+
+// GetAPIKeyByHash method for getting one API key by given key hash.
+func (s *Store) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return models.APIKey{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.KeyHash == hash {
+			return key, nil
+		}
+	}
+
+	return models.APIKey{}, sql.ErrNoRows
+}
+
+// TouchAPIKey method for saving the last usage time of API key by given ID.
+func (s *Store) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[id]; ok {
+		now := time.Now()
+		key.LastUsedAt = &now
+		s.keys[id] = key
+	}
+
+	return nil
+}
+
+// InTx method runs repository calls of fn in one transaction.
+// Transactions run one by one, changes of fn are undone, if it returns error or panics.
+// Calls made outside of transactions are not isolated from it, but their changes
+// of other rows are kept on rollback.
+func (s *Store) InTx(ctx context.Context, fn func(tx repository.Repositories) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	tx := &txStore{
+		Store:        s,
+		usersBefore:  map[uuid.UUID]*models.User{},
+		housesBefore: map[uuid.UUID]*models.House{},
+	}
+
+	defer func() {
+		// Rollback on panic and pass the panic on.
+		if p := recover(); p != nil {
+			tx.rollback()
+			panic(p)
+		}
+
+		// Rollback on error of fn.
+		if err != nil {
+			tx.rollback()
+		}
+	}()
+
+	return fn(repository.Repositories{Users: tx, Houses: tx, Auth: tx})
+}
+
+// insertUser method for saving a new user, the caller must hold the lock.
+func (s *Store) insertUser(user *models.User) error {
+	if _, ok := s.users[user.ID]; ok {
+		return ErrDuplicateID
+	}
+
+	for _, stored := range s.users {
+		if stored.Email == user.Email {
+			return ErrDuplicateEmail
+		}
+	}
+
+	if !rbac.Role(user.Role).Valid() {
+		return ErrInvalidRole
+	}
+
+	// Email is not verified on insert, like in PostgreSQL.
+	stored := *user
+	stored.VerifiedAt = nil
+	s.users[user.ID] = stored
+
+	return nil
+}
+
+// updateUser method for changing profile of the user, the caller must hold the lock.
+func (s *Store) updateUser(id uuid.UUID, user *models.User) error {
+	stored, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+
+	if !rbac.Role(user.Role).Valid() {
+		return ErrInvalidRole
+	}
+
+	stored.Name = user.Name
+	stored.Role = user.Role
+	s.users[id] = stored
+
+	return nil
+}
+
+// deleteUser method for deleting user without houses, the caller must hold the lock.
+func (s *Store) deleteUser(id uuid.UUID) error {
+	for _, house := range s.houses {
+		if house.OwnerID == id {
+			return ErrOwnerHasHouses
+		}
+	}
+
+	delete(s.users, id)
+
+	return nil
+}
+
+// updatePasswordHash method for replacing password hash of the user, the caller must hold the lock.
+func (s *Store) updatePasswordHash(id uuid.UUID, hash string) {
+	if user, ok := s.users[id]; ok {
+		user.Password = hash
+		s.users[id] = user
+	}
+}
+
+// insertHouse method for saving a new house, the caller must hold the lock.
+func (s *Store) insertHouse(house *models.House) error {
+	if _, ok := s.houses[house.ID]; ok {
+		return ErrDuplicateID
+	}
+
+	if _, ok := s.users[house.OwnerID]; !ok {
+		return ErrUnknownOwner
+	}
+
+	s.houses[house.ID] = *house
+
+	return nil
+}
+
+// updateHouse method for changing description and address of the house, the caller must hold the lock.
+func (s *Store) updateHouse(id uuid.UUID, house *models.House) {
+	if stored, ok := s.houses[id]; ok {
+		stored.Description = house.Description
+		stored.Address = house.Address
+		s.houses[id] = stored
+	}
+}
+
+// txStore struct to describe repositories of one transaction.
+// Reads go to the store, writes keep rows as they were before the first change,
+// so rollback undoes only the rows changed by the transaction.
+type txStore struct {
+	*Store
+	usersBefore  map[uuid.UUID]*models.User  // nil for rows created by the transaction
+	housesBefore map[uuid.UUID]*models.House // nil for rows created by the transaction
+}
+
+// CreateUser method for creating user by given User object in transaction.
+func (tx *txStore) CreateUser(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.changeUser(user.ID, func() error { return tx.insertUser(user) })
+}
+
+// UpdateUser method for updating profile of user by given User object in transaction.
+func (tx *txStore) UpdateUser(ctx context.Context, id uuid.UUID, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.changeUser(id, func() error { return tx.updateUser(id, user) })
+}
+
+// DeleteUser method for delete user by given ID in transaction.
+func (tx *txStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.changeUser(id, func() error { return tx.deleteUser(id) })
+}
+
+// UpdatePasswordHash method for replacing stored password hash by given user ID in transaction.
+func (tx *txStore) UpdatePasswordHash(ctx context.Context, id uuid.UUID, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.changeUser(id, func() error {
+		tx.updatePasswordHash(id, hash)
+		return nil
+	})
+}
+
+// RegisterUser method for creating user by given User object in transaction.
+func (tx *txStore) RegisterUser(ctx context.Context, user *models.User) (*uuid.UUID, error) {
+	if err := tx.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	id := user.ID
+
+	return &id, nil
+}
+
+// CreateHouse method for creating house by given House object in transaction.
+func (tx *txStore) CreateHouse(ctx context.Context, house *models.House) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.changeHouse(house.ID, func() error { return tx.insertHouse(house) })
+}
+
+// UpdateHouseById method for updating house by given House object in transaction.
+func (tx *txStore) UpdateHouseById(ctx context.Context, id uuid.UUID, house *models.House) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.changeHouse(id, func() error {
+		tx.updateHouse(id, house)
+		return nil
+	})
+}
+
+// DeleteHouseByID method for delete house by given ID in transaction.
+func (tx *txStore) DeleteHouseByID(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.changeHouse(id, func() error {
+		delete(tx.houses, id)
+		return nil
+	})
+}
+
+// changeUser method for changing the user row and keeping it as it was before
+// the first successful change, the caller must hold the lock.
+func (tx *txStore) changeUser(id uuid.UUID, change func() error) error {
+	before, existed := tx.users[id]
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	if _, ok := tx.usersBefore[id]; !ok {
+		if existed {
+			tx.usersBefore[id] = &before
+		} else {
+			tx.usersBefore[id] = nil
+		}
+	}
+
+	return nil
+}
+
+// changeHouse method for changing the house row and keeping it as it was before
+// the first successful change, the caller must hold the lock.
+func (tx *txStore) changeHouse(id uuid.UUID, change func() error) error {
+	before, existed := tx.houses[id]
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	if _, ok := tx.housesBefore[id]; !ok {
+		if existed {
+			tx.housesBefore[id] = &before
+		} else {
+			tx.housesBefore[id] = nil
+		}
+	}
+
+	return nil
+}
+
+// rollback method for putting back rows changed by the transaction.
+func (tx *txStore) rollback() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	for id, user := range tx.usersBefore {
+		if user == nil {
+			delete(tx.users, id)
+		} else {
+			tx.users[id] = *user
+		}
+	}
+
+	for id, house := range tx.housesBefore {
+		if house == nil {
+			delete(tx.houses, id)
+		} else {
+			tx.houses[id] = *house
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/app/repository"
+	"github.com/popeskul/houser/app/repository/repositorytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) (repository.Repositories, repository.Transactor) {
+		store := NewStore()
+
+		return repository.Repositories{Users: store, Houses: store, Auth: store}, store
+	})
+}
+
+func TestStoreAPIKeyContract(t *testing.T) {
+	repositorytest.TestAPIKeyRepository(t, NewStore())
+}
+
+func TestStoreConcurrentUse(t *testing.T) {
+	store := NewStore()
+
+	// Create users and their houses from many goroutines.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			user := repositorytest.NewUser()
+			assert.NoError(t, store.CreateUser(context.Background(), &user))
+
+			house := repositorytest.NewHouse(user.ID)
+			assert.NoError(t, store.CreateHouse(context.Background(), &house))
+
+			_, err := store.GetHousesByOwner(context.Background(), user.ID)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	users, err := store.GetUsers(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, users, 50)
+
+	houses, err := store.GetHouses(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, houses, 50)
+}
+
+func TestStoreRollbackKeepsOutsideWrites(t *testing.T) {
+	store := NewStore()
+	ctx := context.Background()
+
+	user := repositorytest.NewUser()
+	assert.NoError(t, store.CreateUser(ctx, &user))
+
+	outside := repositorytest.NewUser()
+	inside := repositorytest.NewHouse(user.ID)
+
+	err := store.InTx(ctx, func(tx repository.Repositories) error {
+		assert.NoError(t, tx.Houses.CreateHouse(ctx, &inside))
+		assert.NoError(t, tx.Users.UpdateUser(ctx, user.ID, &models.User{Name: "Renamed", Role: user.Role}))
+
+		// Another request writes while the transaction is running.
+		assert.NoError(t, store.CreateUser(ctx, &outside))
+
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	// Only changes of the transaction are undone.
+	_, err = store.GetHouseById(ctx, inside.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	found, err := store.GetUserById(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Name, found.Name)
+
+	_, err = store.GetUserById(ctx, outside.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,63 @@
+// Package repository describes storage of the app models, so handlers do not
+// depend on a concrete database. PostgreSQL implementation is in app/queries,
+// in-memory implementation for tests is in app/repository/memory.
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+)
+
+// UserRepository interface to describe storage of users.
+// Methods, which look for one user, return sql.ErrNoRows, if it is not found.
+type UserRepository interface {
+	GetUsers(ctx context.Context) ([]models.User, error)
+	GetUserById(ctx context.Context, id uuid.UUID) (models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, id uuid.UUID, user *models.User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+}
+
+// HouseRepository interface to describe storage of houses.
+// Methods, which look for one house, return sql.ErrNoRows, if it is not found.
+type HouseRepository interface {
+	GetHouses(ctx context.Context) ([]models.House, error)
+	GetHousesByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.House, error)
+	GetHouseById(ctx context.Context, id uuid.UUID) (models.House, error)
+	GetHouseByIdForUpdate(ctx context.Context, id uuid.UUID) (models.House, error)
+	CreateHouse(ctx context.Context, house *models.House) error
+	UpdateHouseById(ctx context.Context, id uuid.UUID, house *models.House) error
+	DeleteHouseByID(ctx context.Context, id uuid.UUID) error
+}
+
+// AuthRepository interface to describe storage of user credentials.
+// Methods, which look for one user, return sql.ErrNoRows, if it is not found.
+type AuthRepository interface {
+	Login(ctx context.Context, email string) (models.User, error)
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, hash string) error
+	RegisterUser(ctx context.Context, user *models.User) (*uuid.UUID, error)
+}
+
+// APIKeyRepository interface to describe storage of personal API keys.
+// Methods, which look for one key, return sql.ErrNoRows, if it is not found.
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, k *models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+// Repositories struct to describe repositories, which share one storage.
+type Repositories struct {
+	Users  UserRepository
+	Houses HouseRepository
+	Auth   AuthRepository
+}
+
+// Transactor interface to describe storage, which runs several repository calls atomically.
+// InTx rolls back all changes of fn, if it returns error or panics. Storage may run fn
+// again on conflict with concurrent transaction, so fn must not have other side effects.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(tx Repositories) error) error
+}
@@ -0,0 +1,372 @@
+// Package repositorytest keeps contract tests, which every implementation of
+// app/repository interfaces must pass. Tests create their own rows with random
+// IDs and emails, so they can run against a database with other data.
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run func runs all contract tests against storage, which is created by the
+// given func for every test. All repositories must share the same storage,
+// e.g. houses refer to users, and transactions must see them.
+func Run(t *testing.T, newStorage func(t *testing.T) (repository.Repositories, repository.Transactor)) {
+	t.Run("UserRepository", func(t *testing.T) {
+		r, _ := newStorage(t)
+		TestUserRepository(t, r)
+	})
+	t.Run("HouseRepository", func(t *testing.T) {
+		r, _ := newStorage(t)
+		TestHouseRepository(t, r)
+	})
+	t.Run("AuthRepository", func(t *testing.T) {
+		r, _ := newStorage(t)
+		TestAuthRepository(t, r)
+	})
+	t.Run("Transactor", func(t *testing.T) {
+		r, tr := newStorage(t)
+		TestTransactor(t, r, tr)
+	})
+}
+
+// TestUserRepository func checks contract of repository.UserRepository.
+func TestUserRepository(t *testing.T, r repository.Repositories) {
+	ctx := context.Background()
+	user := NewUser()
+
+	// Unknown users are not found.
+	_, err := r.Users.GetUserById(ctx, user.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Queries with canceled context fail.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, r.Users.CreateUser(canceled, &user), context.Canceled)
+	_, err = r.Users.GetUsers(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Created user is found by ID and in the list, email is not verified yet.
+	user.VerifiedAt = &user.CreatedAt
+	require.NoError(t, r.Users.CreateUser(ctx, &user))
+
+	found, err := r.Users.GetUserById(ctx, user.ID)
+	require.NoError(t, err)
+	assertUser(t, user, found)
+	assert.Nil(t, found.VerifiedAt)
+
+	users, err := r.Users.GetUsers(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, userIDs(users), user.ID)
+
+	// Users with the same email or unknown role are rejected.
+	duplicate := NewUser()
+	duplicate.Email = user.Email
+	assert.Error(t, r.Users.CreateUser(ctx, &duplicate))
+
+	invalid := NewUser()
+	invalid.Role = "root"
+	assert.Error(t, r.Users.CreateUser(ctx, &invalid))
+
+	// Update changes profile only, email and password stay the same.
+	update := models.User{Name: "Updated", Role: "agent", Email: "other@mail.com", Password: "other"}
+	require.NoError(t, r.Users.UpdateUser(ctx, user.ID, &update))
+
+	found, err = r.Users.GetUserById(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", found.Name)
+	assert.Equal(t, "agent", found.Role)
+	assert.Equal(t, user.Email, found.Email)
+	assert.Equal(t, user.Password, found.Password)
+
+	// Deleted user is not found anymore.
+	require.NoError(t, r.Users.DeleteUser(ctx, user.ID))
+
+	_, err = r.Users.GetUserById(ctx, user.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// TestHouseRepository func checks contract of repository.HouseRepository.
+func TestHouseRepository(t *testing.T, r repository.Repositories) {
+	ctx := context.Background()
+	owner := NewUser()
+	require.NoError(t, r.Users.CreateUser(ctx, &owner))
+
+	// Unknown houses are not found.
+	_, err := r.Houses.GetHouseById(ctx, uuid.New())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Houses of unknown owners are rejected.
+	orphan := NewHouse(uuid.New())
+	assert.Error(t, r.Houses.CreateHouse(ctx, &orphan))
+
+	// Created houses are found by ID, in the list and by owner, newest first.
+	older := NewHouse(owner.ID)
+	older.CreatedAt = older.CreatedAt.Add(-time.Hour)
+	require.NoError(t, r.Houses.CreateHouse(ctx, &older))
+
+	newer := NewHouse(owner.ID)
+	require.NoError(t, r.Houses.CreateHouse(ctx, &newer))
+
+	found, err := r.Houses.GetHouseById(ctx, newer.ID)
+	require.NoError(t, err)
+	assertHouse(t, newer, found)
+
+	houses, err := r.Houses.GetHouses(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, houseIDs(houses), older.ID)
+	assert.Contains(t, houseIDs(houses), newer.ID)
+
+	owned, err := r.Houses.GetHousesByOwner(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{newer.ID, older.ID}, houseIDs(owned))
+
+	// Owners without houses get an empty list.
+	owned, err = r.Houses.GetHousesByOwner(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, owned)
+
+	// Update changes description and address only.
+	update := models.House{Description: "Updated", Address: "New street 1", OwnerID: uuid.New()}
+	require.NoError(t, r.Houses.UpdateHouseById(ctx, newer.ID, &update))
+
+	found, err = r.Houses.GetHouseById(ctx, newer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", found.Description)
+	assert.Equal(t, "New street 1", found.Address)
+	assert.Equal(t, owner.ID, found.OwnerID)
+
+	// Deleted houses are not found anymore.
+	require.NoError(t, r.Houses.DeleteHouseByID(ctx, older.ID))
+	require.NoError(t, r.Houses.DeleteHouseByID(ctx, newer.ID))
+
+	_, err = r.Houses.GetHouseById(ctx, newer.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, r.Users.DeleteUser(ctx, owner.ID))
+}
+
+// TestAuthRepository func checks contract of repository.AuthRepository.
+func TestAuthRepository(t *testing.T, r repository.Repositories) {
+	ctx := context.Background()
+	user := NewUser()
+
+	// Unknown emails are not found.
+	_, err := r.Auth.Login(ctx, user.Email)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Registered user is found by email with the password hash.
+	id, err := r.Auth.RegisterUser(ctx, &user)
+	require.NoError(t, err)
+	require.NotNil(t, id)
+	assert.Equal(t, user.ID, *id)
+
+	found, err := r.Auth.Login(ctx, user.Email)
+	require.NoError(t, err)
+	assertUser(t, user, found)
+
+	// Second sign up with the same email is rejected.
+	duplicate := NewUser()
+	duplicate.Email = user.Email
+	_, err = r.Auth.RegisterUser(ctx, &duplicate)
+	assert.Error(t, err)
+
+	// Password hash is replaced.
+	require.NoError(t, r.Auth.UpdatePasswordHash(ctx, user.ID, "new-hash"))
+
+	found, err = r.Auth.Login(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", found.Password)
+
+	require.NoError(t, r.Users.DeleteUser(ctx, user.ID))
+}
+
+// TestTransactor func checks contract of repository.Transactor.
+func TestTransactor(t *testing.T, r repository.Repositories, tr repository.Transactor) {
+	ctx := context.Background()
+	owner := NewUser()
+	require.NoError(t, r.Users.CreateUser(ctx, &owner))
+	house := NewHouse(owner.ID)
+	require.NoError(t, r.Houses.CreateHouse(ctx, &house))
+
+	// Changes of failed transaction are rolled back.
+	errFailed := errors.New("failed")
+	err := tr.InTx(ctx, func(tx repository.Repositories) error {
+		update := models.House{Description: "Rolled back", Address: house.Address}
+		require.NoError(t, tx.Houses.UpdateHouseById(ctx, house.ID, &update))
+
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+
+	found, err := r.Houses.GetHouseById(ctx, house.ID)
+	require.NoError(t, err)
+	assert.Equal(t, house.Description, found.Description)
+
+	// Changes of panicked transaction are rolled back, the panic goes on.
+	assert.Panics(t, func() {
+		_ = tr.InTx(ctx, func(tx repository.Repositories) error {
+			require.NoError(t, tx.Houses.DeleteHouseByID(ctx, house.ID))
+
+			panic("failed")
+		})
+	})
+
+	_, err = r.Houses.GetHouseById(ctx, house.ID)
+	assert.NoError(t, err)
+
+	// Locked reads of concurrent transactions see changes of each other.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, tr.InTx(ctx, func(tx repository.Repositories) error {
+				found, err := tx.Houses.GetHouseByIdForUpdate(ctx, house.ID)
+				if err != nil {
+					return err
+				}
+
+				found.Description += "+"
+
+				return tx.Houses.UpdateHouseById(ctx, house.ID, &found)
+			}))
+		}()
+	}
+	wg.Wait()
+
+	found, err = r.Houses.GetHouseById(ctx, house.ID)
+	require.NoError(t, err)
+	assert.Equal(t, house.Description+"++++++++++", found.Description)
+
+	require.NoError(t, r.Houses.DeleteHouseByID(ctx, house.ID))
+	require.NoError(t, r.Users.DeleteUser(ctx, owner.ID))
+}
+
+// TestAPIKeyRepository func checks contract of repository.APIKeyRepository.
+func TestAPIKeyRepository(t *testing.T, r repository.APIKeyRepository) {
+	ctx := context.Background()
+	key := NewAPIKey(uuid.New())
+
+	// Unknown keys are not found.
+	_, err := r.GetAPIKeyByHash(ctx, key.KeyHash)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Created key is found by its hash with scopes, it was not used yet.
+	require.NoError(t, r.CreateAPIKey(ctx, &key))
+
+	found, err := r.GetAPIKeyByHash(ctx, key.KeyHash)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, found.ID)
+	assert.Equal(t, key.UserID, found.UserID)
+	assert.Equal(t, []string(key.Scopes), []string(found.Scopes))
+	assert.Nil(t, found.LastUsedAt)
+	assert.Nil(t, found.RevokedAt)
+
+	// Keys with the same hash are rejected.
+	duplicate := NewAPIKey(key.UserID)
+	duplicate.KeyHash = key.KeyHash
+	assert.Error(t, r.CreateAPIKey(ctx, &duplicate))
+
+	// Usage time is saved.
+	require.NoError(t, r.TouchAPIKey(ctx, key.ID))
+
+	found, err = r.GetAPIKeyByHash(ctx, key.KeyHash)
+	require.NoError(t, err)
+	assert.NotNil(t, found.LastUsedAt)
+}
+
+// NewUser func returns a new user with random ID and email.
+func NewUser() models.User {
+	id := uuid.New()
+
+	return models.User{
+		ID:        id,
+		Name:      "Test",
+		Email:     id.String() + "@mail.com",
+		Password:  "hash",
+		Role:      "owner",
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// NewHouse func returns a new house of the given owner with random ID.
+func NewHouse(ownerID uuid.UUID) models.House {
+	return models.House{
+		ID:          uuid.New(),
+		Description: "Test house",
+		Address:     "Test street 1",
+		OwnerID:     ownerID,
+		CreatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// NewAPIKey func returns a new API key of the given user with random ID and hash.
+func NewAPIKey(userID uuid.UUID) models.APIKey {
+	id := uuid.New()
+
+	return models.APIKey{
+		ID:        id,
+		UserID:    userID,
+		Name:      "Test",
+		Prefix:    id.String()[:8],
+		KeyHash:   strings.ReplaceAll(uuid.New().String()+id.String(), "-", ""),
+		Scopes:    []string{"houses:read"},
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// assertUser func compares stored user with the expected one.
+func assertUser(t *testing.T, expected, actual models.User) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Name, actual.Name)
+	assert.Equal(t, expected.Email, actual.Email)
+	assert.Equal(t, expected.Password, actual.Password)
+	assert.Equal(t, expected.Role, actual.Role)
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created at %v, got %v", expected.CreatedAt, actual.CreatedAt)
+}
+
+// assertHouse func compares stored house with the expected one.
+func assertHouse(t *testing.T, expected, actual models.House) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.Equal(t, expected.Address, actual.Address)
+	assert.Equal(t, expected.OwnerID, actual.OwnerID)
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created at %v, got %v", expected.CreatedAt, actual.CreatedAt)
+}
+
+// userIDs func returns IDs of the given users.
+func userIDs(users []models.User) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	return ids
+}
+
+// houseIDs func returns IDs of the given houses.
+func houseIDs(houses []models.House) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(houses))
+	for _, house := range houses {
+		ids = append(ids, house.ID)
+	}
+
+	return ids
+}
@@ -1,11 +1,10 @@
 package main
 
 import (
-	"github.com/gofiber/fiber/v2"
-	"github.com/popeskul/houser/pkg/configs"
-	"github.com/popeskul/houser/pkg/middleware"
-	"github.com/popeskul/houser/pkg/routes"
-	"github.com/popeskul/houser/pkg/utils"
+	"context"
+	"os"
+
+	"github.com/popeskul/houser/pkg/cli"
 )
 
 // @title Houser API
@@ -16,26 +15,11 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey PersonalAPIKey
+// @in header
+// @name X-API-Key
 // @BasePath /api
 func main() {
-	// Define env and viper
-	configs.EnvConfigs()
-
-	// Define Fiber config.
-	config := configs.FiberConfig()
-
-	// Define a new Fiber app with config.
-	app := fiber.New(config)
-
-	// Middlewares.
-	middleware.FiberMiddleware(app) // Register Fiber's middleware for app.
-
-	// Routes.
-	routes.SwaggerRoute(app)  // Register a route for API Docs (Swagger).
-	routes.PublicRoutes(app)  // Register a public routes for app.
-	routes.PrivateRoutes(app) // Register a private routes for app.
-	routes.NotFoundRoute(app) // Register route for 404 Error.
-
-	// Start server (with graceful shutdown).
-	utils.StartServerWithGracefulShutdown(app)
+	// Run command of the binary, e.g. "houser serve", see pkg/cli.
+	os.Exit(cli.Run(context.Background(), os.Args[1:], &cli.Streams{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}))
 }
@@ -0,0 +1,259 @@
+// Package cli keeps commands of houser binary, e.g. "houser serve" starts
+// the HTTP server and "houser user create --admin" bootstraps the first admin.
+// All commands load configuration the same way, see configs.EnvConfigs.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/keystore"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
+	"github.com/popeskul/houser/platform/audit"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/revocation"
+)
+
+// Exit codes of the binary.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// errUsage is returned by commands for invalid arguments, usage of the command is printed for it.
+var errUsage = errors.New("invalid usage")
+
+// Streams struct to describe input and output of commands.
+type Streams struct {
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// Command struct to describe one command of the binary.
+// Commands with subcommands have no Run func.
+type Command struct {
+	Name     string
+	Usage    string // arguments, which are shown after the name
+	Summary  string
+	Run      func(ctx context.Context, s *Streams, args []string) error
+	Commands []*Command
+}
+
+// Root func returns all commands of the binary.
+func Root() *Command {
+	return &Command{
+		Name: "houser",
+		Commands: []*Command{
+			serveCommand(),
+			migrateCommand(),
+			seedCommand(),
+			userCommand(),
+			tokenCommand(),
+		},
+	}
+}
+
+// Run func finds the command by the given arguments, loads configuration
+// and runs the command. It returns exit code of the binary.
+// Flags before the command are shared by all commands, e.g. "houser --config FILE serve".
+func Run(ctx context.Context, args []string, s *Streams) int {
+	root := Root()
+
+	// Parse shared flags.
+	flags := newFlagSet(root.Name, s)
+	flags.Usage = func() { root.usage(s.Err, []string{root.Name}) }
+	configPath := flags.String("config", "", "path of .yml config file, configs/config.yml by default")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+
+		return ExitUsage
+	}
+
+	// Find the command, arguments after its name belong to the command.
+	path, cmd, args := root.find(flags.Args())
+	if cmd.Run == nil {
+		if len(args) > 0 && !isHelp(args[0]) {
+			fmt.Fprintf(s.Err, "unknown command %q\n\n", strings.Join(append(path, args[0]), " "))
+			cmd.usage(s.Err, path)
+
+			return ExitUsage
+		}
+
+		// Print list of subcommands for "houser", "houser user" and "houser help".
+		if len(args) > 0 {
+			cmd.usage(s.Out, path)
+
+			return ExitOK
+		}
+		cmd.usage(s.Err, path)
+
+		return ExitUsage
+	}
+
+	// Define env and viper, invalid configuration stops any command.
+	if err := configs.EnvConfigs(*configPath); err != nil {
+		fmt.Fprintf(s.Err, "%s: %v\n", strings.Join(path, " "), err)
+
+		return ExitError
+	}
+
+	err := cmd.Run(ctx, s, args)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, errUsage):
+		if err != errUsage {
+			fmt.Fprintln(s.Err, err)
+		}
+		cmd.usage(s.Err, path)
+
+		return ExitUsage
+	default:
+		fmt.Fprintf(s.Err, "%s: %v\n", strings.Join(path, " "), err)
+
+		return ExitError
+	}
+}
+
+// find method for look up subcommand by leading arguments.
+// It returns names of the found command, the command and the rest of arguments.
+func (c *Command) find(args []string) ([]string, *Command, []string) {
+	path := []string{c.Name}
+	for len(args) > 0 {
+		var next *Command
+		for _, sub := range c.Commands {
+			if sub.Name == args[0] {
+				next = sub
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		c, args = next, args[1:]
+		path = append(path, c.Name)
+	}
+
+	return path, c, args
+}
+
+// usage method for print usage of the command with the given names.
+func (c *Command) usage(w io.Writer, path []string) {
+	name := strings.Join(path, " ")
+	if c.Run != nil {
+		fmt.Fprintf(w, "usage: %s %s\n", name, c.Usage)
+		if c.Summary != "" {
+			fmt.Fprintf(w, "\n%s\n", c.Summary)
+		}
+
+		return
+	}
+
+	if len(path) == 1 {
+		name += " [--config FILE]"
+	}
+	fmt.Fprintf(w, "usage: %s <command> [arguments]\n\ncommands:\n", name)
+	for _, sub := range c.Commands {
+		fmt.Fprintf(w, "  %-16s %s\n", sub.Name, sub.Summary)
+	}
+}
+
+// isHelp func reports whether argument asks for help.
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// newFlagSet func for create flags of the command, errors are written to stderr.
+func newFlagSet(name string, s *Streams) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(s.Err)
+
+	return flags
+}
+
+// parseFlags func for parse flags of the command, positional arguments are not allowed.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, flags.Arg(0))
+	}
+
+	return nil
+}
+
+// readPassword func returns password from the flag, or reads the first line
+// of input, so password is not kept in shell history.
+func readPassword(s *Streams, password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	if f, ok := s.In.(*os.File); ok && f == os.Stdin {
+		fmt.Fprint(s.Err, "Password: ")
+	}
+
+	line, err := bufio.NewReader(s.In).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%w: password is required", errUsage)
+	}
+
+	return password, nil
+}
+
+// openContainer func for create dependencies of the app and make them default.
+func openContainer() (*container.Container, error) {
+	deps, err := container.New()
+	if err != nil {
+		return nil, err
+	}
+	revocation.SetDefault(revocation.NewStore(revocation.NewDatabaseBackend(deps.DB), configs.Default().JWT.RevocationCacheTTL))
+	audit.SetDefault(audit.NewDatabaseLogger(deps.DB))
+
+	return deps, nil
+}
+
+// loadKeys func for define JWT signing keys.
+func loadKeys() (*keystore.KeyStore, error) {
+	keys, err := keystore.Load()
+	if err != nil {
+		return nil, err
+	}
+	keystore.SetDefault(keys)
+
+	return keys, nil
+}
+
+// loadPasswordPolicy func for define password policy.
+func loadPasswordPolicy() error {
+	policy, err := passwordpolicy.Load()
+	if err != nil {
+		return err
+	}
+	passwordpolicy.SetDefault(policy)
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run func runs the binary with the given arguments and input.
+func run(args []string, input string) (code int, stdout string, stderr string) {
+	var out, errOut bytes.Buffer
+	code = Run(context.Background(), args, &Streams{In: strings.NewReader(input), Out: &out, Err: &errOut})
+
+	return code, out.String(), errOut.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	// Define the only required setting, configs/config.yml is not found in tests.
+	t.Setenv("HOUSER_JWT_SECRET_KEY", "secret")
+	defer viper.Reset()
+
+	tests := []struct {
+		description string
+		args        []string
+		code        int
+		stdout      string
+		stderr      string
+	}{
+		{"no command", nil, ExitUsage, "", "commands:"},
+		{"help", []string{"help"}, ExitOK, "token", ""},
+		{"help of group", []string{"user", "--help"}, ExitOK, "create", ""},
+		{"unknown command", []string{"start"}, ExitUsage, "", `unknown command "houser start"`},
+		{"unknown subcommand", []string{"migrate", "sideways"}, ExitUsage, "", `unknown command "houser migrate sideways"`},
+		{"group without subcommand", []string{"token"}, ExitUsage, "", "issue"},
+		{"unexpected argument", []string{"serve", "now"}, ExitUsage, "", `unexpected argument "now"`},
+		{"unknown flag", []string{"migrate", "up", "--force"}, ExitUsage, "", "usage: houser migrate up"},
+		{"migrate to without version", []string{"migrate", "to"}, ExitUsage, "", "usage: houser migrate to VERSION"},
+		{"migrate to invalid version", []string{"migrate", "to", "latest"}, ExitUsage, "", `invalid version "latest"`},
+		{"create user without email", []string{"user", "create", "--admin"}, ExitUsage, "", "email is required"},
+		{"create user with unknown role", []string{"user", "create", "--email", "a@mail.com", "--role", "root"}, ExitUsage, "", `unknown role "root"`},
+		{"create admin with other role", []string{"user", "create", "--email", "a@mail.com", "--admin", "--role", "viewer"}, ExitUsage, "", "--admin can not be used"},
+		{"create user without password", []string{"user", "create", "--email", "a@mail.com"}, ExitUsage, "", "password is required"},
+		{"reset password without email", []string{"user", "reset-password"}, ExitUsage, "", "email is required"},
+		{"issue token without email", []string{"token", "issue"}, ExitUsage, "", "email is required"},
+		{"seed negative houses", []string{"seed", "--houses", "-1"}, ExitUsage, "", "must not be negative"},
+		{"unknown shared flag", []string{"--verbose", "serve"}, ExitUsage, "", "usage: houser [--config FILE] <command>"},
+		{"missing config file", []string{"--config", "missing.yml", "serve"}, ExitError, "", "houser serve: config file:"},
+	}
+
+	for _, test := range tests {
+		code, stdout, stderr := run(test.args, "")
+
+		assert.Equal(t, test.code, code, test.description)
+		assert.Contains(t, stdout, test.stdout, test.description)
+		assert.Contains(t, stderr, test.stderr, test.description)
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	defer viper.Reset()
+
+	// Commands are not run with invalid configuration.
+	t.Setenv("HOUSER_JWT_SECRET_KEY", "")
+	t.Setenv("HOUSER_DB_MAX_CONNECTIONS", "many")
+
+	code, _, stderr := run([]string{"migrate", "up"}, "")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, `db.max_connections must be a non-negative integer, got "many"`)
+
+	t.Setenv("HOUSER_DB_MAX_CONNECTIONS", "10")
+
+	code, _, stderr = run([]string{"migrate", "up"}, "")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "jwt_secret_key is required for HS256")
+}
+
+func TestReadPassword(t *testing.T) {
+	// Password from flag is used as is.
+	password, err := readPassword(&Streams{In: strings.NewReader("input\n")}, "flag")
+	assert.NoError(t, err)
+	assert.Equal(t, "flag", password)
+
+	// Otherwise the first line of input is read.
+	password, err = readPassword(&Streams{In: strings.NewReader("first line\r\nsecond line\n")}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "first line", password)
+
+	// Input without line break is the password too.
+	password, err = readPassword(&Streams{In: strings.NewReader("no line break")}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "no line break", password)
+
+	// Empty input is invalid usage.
+	_, err = readPassword(&Streams{In: strings.NewReader("\n")}, "")
+	assert.True(t, errors.Is(err, errUsage))
+}
+
+func TestUserCommands(t *testing.T) {
+	// Define database and JWT settings of the test environment, the rest is default.
+	t.Setenv("HOUSER_DB_PASSWORD", "123123")
+	t.Setenv("HOUSER_JWT_SECRET_KEY", "secret")
+	defer viper.Reset()
+	defer configs.SetDefault(nil)
+	require.NoError(t, configs.EnvConfigs(""))
+
+	// Skip, if PostgreSQL is not running.
+	db, err := database.Open()
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	email := uuid.New().String() + "@mail.com"
+
+	// The first admin is created with password from input.
+	code, stdout, stderr := run([]string{"user", "create", "--email", email, "--name", "Admin", "--admin"}, "first-Passw0rd\n")
+	require.Equal(t, ExitOK, code, stderr)
+
+	id, err := uuid.Parse(strings.TrimSpace(stdout))
+	require.NoError(t, err)
+	defer func() { _ = db.DeleteUser(ctx, id) }()
+
+	user, err := db.Login(ctx, email)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "admin", user.Role)
+	assert.NotNil(t, user.VerifiedAt)
+
+	match, _, err := utils.VerifyPassword(user.Password, "first-Passw0rd")
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	// The second user with the same email is rejected.
+	code, _, _ = run([]string{"user", "create", "--email", email, "--password", "other-Passw0rd"}, "")
+	assert.Equal(t, ExitError, code)
+
+	// Password is replaced.
+	code, _, stderr = run([]string{"user", "reset-password", "--email", email, "--password", "second-Passw0rd"}, "")
+	require.Equal(t, ExitOK, code, stderr)
+
+	user, err = db.Login(ctx, email)
+	require.NoError(t, err)
+	match, _, err = utils.VerifyPassword(user.Password, "second-Passw0rd")
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	// Unknown users get no password and no token.
+	code, _, stderr = run([]string{"user", "reset-password", "--email", "unknown-" + email, "--password", "second-Passw0rd"}, "")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, errUserNotFound.Error())
+
+	// Legacy plaintext passwords are counted.
+	require.NoError(t, db.UpdatePasswordHash(ctx, id, utils.LegacyPlaintextPrefix+"second-Passw0rd"))
+
+	code, stdout, stderr = run([]string{"user", "legacy-passwords"}, "")
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Regexp(t, `legacy plaintext passwords: [1-9]`, stdout)
+
+	// Access token is issued with the role of the user.
+	code, stdout, stderr = run([]string{"token", "issue", "--email", email}, "")
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Equal(t, 3, len(strings.Split(strings.TrimSpace(stdout), ".")))
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/popeskul/houser/platform/database"
+	"github.com/popeskul/houser/platform/migrations"
+)
+
+// migrateCommand func for "houser migrate", it applies schema migrations embedded into the app.
+func migrateCommand() *Command {
+	return &Command{
+		Name:    "migrate",
+		Summary: "apply or revert schema migrations",
+		Commands: []*Command{
+			{
+				Name:    "up",
+				Summary: "apply all new migrations",
+				Run: func(ctx context.Context, s *Streams, args []string) error {
+					if err := parseFlags(newFlagSet("up", s), args); err != nil {
+						return err
+					}
+
+					return withMigrator(func(m *migrations.Migrator) error { return m.Up(ctx) })
+				},
+			},
+			{
+				Name:    "down",
+				Summary: "revert the last migration",
+				Run: func(ctx context.Context, s *Streams, args []string) error {
+					if err := parseFlags(newFlagSet("down", s), args); err != nil {
+						return err
+					}
+
+					return withMigrator(func(m *migrations.Migrator) error { return m.Down(ctx) })
+				},
+			},
+			{
+				Name:    "to",
+				Usage:   "VERSION",
+				Summary: "apply or revert migrations until VERSION, 0 reverts all",
+				Run:     migrateTo,
+			},
+			{
+				Name:    "status",
+				Summary: "print applied version and known migrations",
+				Run:     migrateStatus,
+			},
+		},
+	}
+}
+
+// migrateTo func for "houser migrate to VERSION".
+func migrateTo(ctx context.Context, s *Streams, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	version, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid version %q", errUsage, args[0])
+	}
+
+	return withMigrator(func(m *migrations.Migrator) error { return m.To(ctx, uint(version)) })
+}
+
+// migrateStatus func for "houser migrate status".
+func migrateStatus(ctx context.Context, s *Streams, args []string) error {
+	if err := parseFlags(newFlagSet("status", s), args); err != nil {
+		return err
+	}
+
+	return withMigrator(func(m *migrations.Migrator) error {
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(s.Out, "version: %d, dirty: %t\n", status.Version, status.Dirty)
+		for _, migration := range status.Migrations {
+			fmt.Fprintf(s.Out, "%06d_%s applied: %t\n", migration.Version, migration.Name, migration.Applied)
+		}
+
+		return nil
+	})
+}
+
+// withMigrator func opens database connection pool for the given func and closes it after.
+func withMigrator(fn func(m *migrations.Migrator) error) error {
+	// Open database connection pool.
+	db, err := database.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+
+	return fn(migrator)
+}
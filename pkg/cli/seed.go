@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/rbac"
+)
+
+// seedDomain is the email domain of demo users.
+const seedDomain = "houser.local"
+
+// seedCommand func for "houser seed", it fills database with demo data.
+func seedCommand() *Command {
+	return &Command{
+		Name:    "seed",
+		Usage:   "[--houses COUNT] [--password PASSWORD]",
+		Summary: "create a demo user of every role and houses of the owner, existing users are skipped",
+		Run:     seed,
+	}
+}
+
+// seed func for create demo users and houses.
+func seed(ctx context.Context, s *Streams, args []string) error {
+	flags := newFlagSet("seed", s)
+	houses := flags.Int("houses", 3, "count of houses of the demo owner")
+	password := flags.String("password", "", "password of demo users")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *houses < 0 {
+		return fmt.Errorf("%w: houses count must not be negative", errUsage)
+	}
+
+	var err error
+	*password, err = readPassword(s, *password)
+	if err != nil {
+		return err
+	}
+
+	// Define password policy.
+	if err := loadPasswordPolicy(); err != nil {
+		return err
+	}
+
+	// Define dependencies with database connection pool.
+	deps, err := openContainer()
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	for _, role := range []rbac.Role{rbac.RoleAdmin, rbac.RoleAgent, rbac.RoleOwner, rbac.RoleViewer} {
+		email := string(role) + "@" + seedDomain
+
+		// Checking, if user was seeded before.
+		_, err := deps.Auth.Login(ctx, email)
+		if err == nil {
+			fmt.Fprintf(s.Out, "%s exists, skipped\n", email)
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		user, err := registerVerifiedUser(ctx, deps, email, "Demo "+string(role), *password, role)
+		if err != nil {
+			return fmt.Errorf("%s: %w", email, err)
+		}
+		fmt.Fprintf(s.Out, "%s created\n", email)
+
+		if role != rbac.RoleOwner {
+			continue
+		}
+
+		// Create houses of the demo owner.
+		for i := 1; i <= *houses; i++ {
+			house := &models.House{
+				ID:          uuid.New(),
+				Description: fmt.Sprintf("Demo house %d", i),
+				Address:     fmt.Sprintf("Demo street %d", i),
+				OwnerID:     user.ID,
+				CreatedAt:   time.Now(),
+			}
+			if err := deps.Houses.CreateHouse(ctx, house); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(s.Out, "%d houses of %s created\n", *houses, email)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/middleware"
+	"github.com/popeskul/houser/pkg/routes"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// serveCommand func for "houser serve", it starts the HTTP server.
+func serveCommand() *Command {
+	return &Command{
+		Name:    "serve",
+		Summary: "start the HTTP server",
+		Run:     serve,
+	}
+}
+
+// serve func for start the HTTP server with graceful shutdown.
+func serve(ctx context.Context, s *Streams, args []string) error {
+	if err := parseFlags(newFlagSet("serve", s), args); err != nil {
+		return err
+	}
+
+	// Define JWT signing keys and rotate them on schedule.
+	keys, err := loadKeys()
+	if err != nil {
+		return err
+	}
+	defer keys.StartRotation(time.Minute, func(err error) { logrus.Error(err) })()
+
+	// Define password policy, breached passwords list is read once.
+	if err := loadPasswordPolicy(); err != nil {
+		return err
+	}
+
+	// Define dependencies with the only database connection pool.
+	deps, err := openContainer()
+	if err != nil {
+		return err
+	}
+
+	// Apply new schema migrations, if it is enabled in config.
+	if configs.Default().DB.AutoMigrate {
+		migrator, err := deps.DB.Migrator()
+		if err != nil {
+			deps.Close()
+			return err
+		}
+		if err := migrator.Up(ctx); err != nil {
+			deps.Close()
+			return err
+		}
+	}
+
+	// Define Fiber config.
+	config := configs.FiberConfig()
+
+	// Define a new Fiber app with config.
+	app := fiber.New(config)
+
+	// Middlewares.
+	middleware.FiberMiddleware(app)    // Register Fiber's middleware for app.
+	app.Use(deps.Inject())             // Share dependencies with every request.
+	app.Use(middleware.QueryContext()) // Limit database queries of every request.
+
+	// Routes.
+	routes.SwaggerRoute(app)  // Register a route for API Docs (Swagger).
+	routes.PublicRoutes(app)  // Register a public routes for app.
+	routes.PrivateRoutes(app) // Register a private routes for app.
+	routes.NotFoundRoute(app) // Register route for 404 Error.
+
+	// Start server (with graceful shutdown), the pool is closed after the last request.
+	return utils.StartServerWithGracefulShutdown(app, deps)
+}
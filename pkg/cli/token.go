@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/pkg/utils"
+)
+
+// tokenCommand func for "houser token", it issues tokens for maintenance scripts.
+func tokenCommand() *Command {
+	return &Command{
+		Name:    "token",
+		Summary: "issue access tokens",
+		Commands: []*Command{
+			{
+				Name:    "issue",
+				Usage:   "--email EMAIL",
+				Summary: "print a new access token of the user, it is not bound to a session",
+				Run:     issueToken,
+			},
+		},
+	}
+}
+
+// issueToken func for "houser token issue".
+func issueToken(ctx context.Context, s *Streams, args []string) error {
+	flags := newFlagSet("issue", s)
+	email := flags.String("email", "", "email of the user")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		return fmt.Errorf("%w: email is required", errUsage)
+	}
+
+	// Define JWT signing keys.
+	if _, err := loadKeys(); err != nil {
+		return err
+	}
+
+	// Define dependencies with database connection pool.
+	deps, err := openContainer()
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	// Get user by email, the token gets the current role of the user.
+	user, err := deps.Auth.Login(ctx, *email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Generate a new Access token.
+	token, err := utils.GenerateNewAccessToken(user, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(s.Out, token)
+
+	return nil
+}
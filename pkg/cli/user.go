@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/revocation"
+)
+
+// errUserNotFound is returned for unknown emails.
+var errUserNotFound = errors.New("user with the given email is not found")
+
+// userCommand func for "houser user", it manages accounts without API.
+func userCommand() *Command {
+	return &Command{
+		Name:    "user",
+		Summary: "create users, reset their passwords and check legacy passwords",
+		Commands: []*Command{
+			{
+				Name:    "create",
+				Usage:   "--email EMAIL [--name NAME] [--role ROLE | --admin] [--password PASSWORD]",
+				Summary: "create a user with verified email, password is read from input, if it is not given",
+				Run:     createUser,
+			},
+			{
+				Name:    "reset-password",
+				Usage:   "--email EMAIL [--password PASSWORD]",
+				Summary: "replace password of the user and sign out all sessions, password is read from input, if it is not given",
+				Run:     resetPassword,
+			},
+			{
+				Name:    "legacy-passwords",
+				Summary: "print number of users with legacy plaintext passwords, they are hashed on next sign in",
+				Run:     legacyPasswords,
+			},
+		},
+	}
+}
+
+// createUser func for "houser user create".
+func createUser(ctx context.Context, s *Streams, args []string) error {
+	flags := newFlagSet("create", s)
+	email := flags.String("email", "", "email of the user")
+	name := flags.String("name", "", "name of the user")
+	role := flags.String("role", string(rbac.DefaultRole), "role of the user: admin, agent, owner or viewer")
+	admin := flags.Bool("admin", false, "create admin, same as --role admin")
+	password := flags.String("password", "", "password of the user")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	// Checking, if flags are consistent.
+	if *email == "" {
+		return fmt.Errorf("%w: email is required", errUsage)
+	}
+	if *admin {
+		if *role != string(rbac.DefaultRole) && *role != string(rbac.RoleAdmin) {
+			return fmt.Errorf("%w: --admin can not be used with --role %s", errUsage, *role)
+		}
+		*role = string(rbac.RoleAdmin)
+	}
+	if !rbac.Role(*role).Valid() {
+		return fmt.Errorf("%w: unknown role %q", errUsage, *role)
+	}
+
+	var err error
+	*password, err = readPassword(s, *password)
+	if err != nil {
+		return err
+	}
+
+	// Define password policy.
+	if err := loadPasswordPolicy(); err != nil {
+		return err
+	}
+
+	// Define dependencies with database connection pool.
+	deps, err := openContainer()
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	user, err := registerVerifiedUser(ctx, deps, *email, *name, *password, rbac.Role(*role))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(s.Out, user.ID)
+
+	return nil
+}
+
+// resetPassword func for "houser user reset-password".
+func resetPassword(ctx context.Context, s *Streams, args []string) error {
+	flags := newFlagSet("reset-password", s)
+	email := flags.String("email", "", "email of the user")
+	password := flags.String("password", "", "new password of the user")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		return fmt.Errorf("%w: email is required", errUsage)
+	}
+
+	var err error
+	*password, err = readPassword(s, *password)
+	if err != nil {
+		return err
+	}
+
+	// Define password policy.
+	if err := loadPasswordPolicy(); err != nil {
+		return err
+	}
+
+	// Define dependencies with database connection pool.
+	deps, err := openContainer()
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	// Get user by email.
+	user, err := deps.Auth.Login(ctx, *email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Check password by policy.
+	if err := passwordpolicy.Default().Check(*password, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	// Hash the new password.
+	hash, err := utils.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	// Save the new password.
+	if err := deps.DB.UpdatePasswordHash(ctx, user.ID, hash); err != nil {
+		return err
+	}
+
+	// Invalidate reset links sent to the user.
+	if err := deps.DB.UseUserPasswordResetTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Revoke all sessions of the user.
+	if err := deps.DB.RevokeUserSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Revoke all refresh tokens of the user.
+	if err := deps.DB.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Revoke all access tokens of the user issued until now.
+	return revocation.Default().RevokeAll(ctx, user.ID)
+}
+
+// legacyPasswords func for "houser user legacy-passwords".
+func legacyPasswords(ctx context.Context, s *Streams, args []string) error {
+	if err := parseFlags(newFlagSet("legacy-passwords", s), args); err != nil {
+		return err
+	}
+
+	// Define dependencies with database connection pool.
+	deps, err := openContainer()
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	// Count passwords marked by migration, they are left until the next sign in.
+	count, err := deps.DB.CountPasswordsWithPrefix(ctx, utils.LegacyPlaintextPrefix)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(s.Out, "legacy plaintext passwords: %d\n", count)
+	if count > 0 && !configs.Default().Hasher.LegacyPlaintext {
+		fmt.Fprintln(s.Err, "password.legacy_plaintext is disabled, these users must reset their passwords")
+	}
+
+	return nil
+}
+
+// registerVerifiedUser func for create a user with the given password by policy.
+// Email is marked as verified, accounts created by operators need no confirmation.
+func registerVerifiedUser(ctx context.Context, deps *container.Container, email, name, password string, role rbac.Role) (*models.User, error) {
+	// Set initialized default data for user:
+	user := &models.User{
+		ID:        uuid.New(),
+		Name:      name,
+		Email:     email,
+		Password:  password,
+		Role:      string(role),
+		CreatedAt: time.Now(),
+	}
+
+	// Validate user fields.
+	if err := utils.NewValidator().Struct(user); err != nil {
+		return nil, fmt.Errorf("invalid user: %v", utils.ValidatorErrors(err))
+	}
+
+	// Check password by policy.
+	if err := passwordpolicy.Default().Check(user.Password, user.Email, user.Name); err != nil {
+		return nil, err
+	}
+
+	// Replace given password with its hash.
+	hash, err := utils.HashPassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hash
+
+	// Create a new user.
+	if _, err := deps.Auth.RegisterUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Mark email of the user as verified.
+	if _, err := deps.DB.VerifyUserEmail(ctx, user.ID, user.Email); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,617 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of environment variables, which override .yml file,
+// e.g. HOUSER_DB_PASSWORD overrides "db.password".
+const EnvPrefix = "HOUSER"
+
+// defaults are used for keys, which are missing in .yml file and environment.
+var defaults = map[string]interface{}{
+	"port":                                "8080",
+	"jwt_secret_key_expire_minutes_count": "60",
+	"jwt_refresh_key_expire_hours_count":  "720",
+	"jwt_revocation_cache_seconds_count":  "30",
+	"jwt_signing_algorithm":               "HS256",
+	"jwt_keys_dir":                        "keys",
+	"jwt_key_rotation_hours_count":        "720",
+	"jwt_key_retention_hours_count":       "24",
+	"db.host":                             "localhost",
+	"db.port":                             "5432",
+	"db.username":                         "postgres",
+	"db.dbname":                           "houser_db",
+	"db.sslmode":                          "disable",
+	"db.max_connections":                  "100",
+	"db.max_idle_connections":             "10",
+	"db.max_lifetime_connections":         "2",
+	"db.read_timeout_seconds_count":       "5",
+	"db.write_timeout_seconds_count":      "10",
+	"db.transaction_retries_count":        "3",
+	"db.auto_migrate":                     "false",
+
+	"cookie_mode.enabled":             "false",
+	"cookie_mode.access_cookie_name":  "houser_access",
+	"cookie_mode.csrf_cookie_name":    "houser_csrf",
+	"cookie_mode.refresh_cookie_name": "houser_refresh",
+	"cookie_mode.secure":              "true",
+	"cookie_mode.same_site":           "Strict",
+
+	"password.hasher":                        "argon2id",
+	"password.argon2id.memory":               "65536",
+	"password.argon2id.iterations":           "3",
+	"password.argon2id.parallelism":          "2",
+	"password.bcrypt.cost":                   "10",
+	"password.legacy_plaintext":              "false",
+	"password_policy.min_length":             "8",
+	"password_policy.max_length":             "128",
+	"password_policy.disallow_personal_info": "true",
+
+	"mail.transport": "log",
+	"mail.from":      "Houser <no-reply@houser.local>",
+	"mail.smtp.host": "localhost",
+	"mail.smtp.port": "1025",
+
+	"email_verification.expire_hours_count":            "24",
+	"email_verification.resend_interval_seconds_count": "60",
+	"email_change.expire_hours_count":                  "24",
+	"password_reset.expire_minutes_count":              "30",
+	"password_reset.resend_interval_seconds_count":     "60",
+	"password_reset.send_timeout_seconds_count":        "10",
+	"password_reset.workers_count":                     "2",
+	"password_reset.queue_size_count":                  "100",
+
+	"mfa.issuer":                             "Houser",
+	"mfa.pending_token_expire_minutes_count": "5",
+	"mfa.skew_steps_count":                   "1",
+	"impersonation.expire_minutes_count":     "15",
+
+	"sign_in_throttle.window_minutes_count":            "15",
+	"sign_in_throttle.backoff_base_seconds_count":      "1",
+	"sign_in_throttle.backoff_max_seconds_count":       "300",
+	"sign_in_throttle.account_free_attempts_count":     "3",
+	"sign_in_throttle.account_lockout_threshold_count": "10",
+	"sign_in_throttle.account_lockout_minutes_count":   "30",
+	"sign_in_throttle.ip_free_attempts_count":          "20",
+}
+
+// Config struct to describe typed settings of the app.
+type Config struct {
+	Server            ServerConfig
+	JWT               JWTConfig
+	DB                DBConfig
+	Cookie            CookieConfig
+	Hasher            HasherConfig
+	PasswordPolicy    PasswordPolicyConfig
+	Mail              MailConfig
+	EmailVerification EmailVerificationConfig
+	EmailChange       EmailChangeConfig
+	PasswordReset     PasswordResetConfig
+	MFA               MFAConfig
+	Impersonation     ImpersonationConfig
+	Throttle          ThrottleConfig
+	OIDC              OIDCConfig
+}
+
+// ServerConfig struct to describe settings of the HTTP server.
+type ServerConfig struct {
+	Port int
+}
+
+// JWTConfig struct to describe settings of tokens and their signing keys.
+type JWTConfig struct {
+	SecretKey          string // secret of HS256 keys
+	SigningAlgorithm   string // HS256, RS256 or EdDSA
+	KeysDir            string // private keys for RS256 and EdDSA
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+	RevocationCacheTTL time.Duration
+	KeyRotation        time.Duration
+	KeyRetention       time.Duration
+}
+
+// DBConfig struct to describe settings of PostgreSQL connection pool.
+type DBConfig struct {
+	Host                  string
+	Port                  int
+	Username              string
+	Password              string
+	DBName                string
+	SSLMode               string
+	MaxConnections        int // 0 is unlimited
+	MaxIdleConnections    int
+	MaxConnectionLifetime time.Duration // 0, connections are reused forever
+	ReadTimeout           time.Duration // 0 is no timeout
+	WriteTimeout          time.Duration // 0 is no timeout
+	TransactionRetries    int
+	AutoMigrate           bool
+}
+
+// CookieConfig struct to describe browser sessions with access token in cookie.
+type CookieConfig struct {
+	Enabled           bool
+	AccessCookieName  string
+	CSRFCookieName    string
+	RefreshCookieName string // sent to /api/v1/token/refresh only
+	Domain            string
+	Secure            bool
+	SameSite          string // Strict, Lax or None
+}
+
+// HasherConfig struct to describe hashing of new passwords.
+type HasherConfig struct {
+	Algorithm           string // argon2id or bcrypt
+	Argon2idMemory      uint32 // KiB
+	Argon2idIterations  uint32
+	Argon2idParallelism uint8
+	BcryptCost          int
+	LegacyPlaintext     bool // accept legacy plaintext passwords, see utils.LegacyPlaintextPrefix
+}
+
+// PasswordPolicyConfig struct to describe rules for new passwords.
+type PasswordPolicyConfig struct {
+	MinLength            int
+	MaxLength            int // 0 is unlimited
+	RequireLowercase     bool
+	RequireUppercase     bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	DisallowPersonalInfo bool
+	BreachedHashesFile   string // empty disables breached password check
+}
+
+// MailConfig struct to describe mail transport.
+type MailConfig struct {
+	Transport string // smtp or log
+	From      string
+	LogFile   string // empty for stdout
+	SMTP      SMTPConfig
+}
+
+// SMTPConfig struct to describe SMTP server of smtp transport.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// EmailVerificationConfig struct to describe emails with verification link.
+type EmailVerificationConfig struct {
+	URL            string // link without token
+	TTL            time.Duration
+	ResendInterval time.Duration
+}
+
+// EmailChangeConfig struct to describe emails with confirmation link of the new email.
+type EmailChangeConfig struct {
+	URL string // link without token
+	TTL time.Duration
+}
+
+// PasswordResetConfig struct to describe emails with password reset link.
+type PasswordResetConfig struct {
+	URL            string // link without token
+	TTL            time.Duration
+	ResendInterval time.Duration
+	SendTimeout    time.Duration // limit of one background send
+	Workers        int           // background senders
+	QueueSize      int           // pending sends, further requests are dropped
+}
+
+// MFAConfig struct to describe TOTP second factor.
+type MFAConfig struct {
+	Issuer          string // name of the app in authenticator apps
+	PendingTokenTTL time.Duration
+	SkewSteps       int // allowed clock drift in 30 seconds steps
+}
+
+// ImpersonationConfig struct to describe tokens of admins acting as users.
+type ImpersonationConfig struct {
+	TTL time.Duration
+}
+
+// ThrottleConfig struct to describe slowdown of failed sign in attempts.
+type ThrottleConfig struct {
+	Window                  time.Duration
+	BackoffBase             time.Duration
+	BackoffMax              time.Duration
+	AccountFreeAttempts     int
+	AccountLockoutThreshold int // 0 disables lockout
+	AccountLockout          time.Duration
+	IPFreeAttempts          int
+}
+
+// OIDCConfig struct to describe external identity providers.
+type OIDCConfig struct {
+	RedirectBaseURL string // callback of provider is "<base><name>/callback"
+	Providers       map[string]OIDCProviderConfig
+}
+
+// OIDCProviderConfig struct to describe one identity provider.
+type OIDCProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// DSN method returns connection string of PostgreSQL.
+func (c DBConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=%s password=%s", c.Host, c.Port, c.Username, c.DBName, c.SSLMode, c.Password)
+}
+
+// ValidationError is returned for settings, which are missing or malformed.
+// Every problem names its key, so all of them can be fixed at once.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error method for implement error interface.
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, ", ")
+}
+
+// Load func for read settings from .yml file with the given path, or from
+// configs/config.yml, if path is empty, and from HOUSER_* environment variables.
+// Only the file with explicit path must exist, otherwise defaults and environment are used.
+func Load(path string) (*Config, error) {
+	// Define environment overrides, "db.password" is HOUSER_DB_PASSWORD.
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
+	for key, value := range defaults {
+		viper.SetDefault(key, value)
+	}
+
+	if path != "" {
+		viper.SetConfigFile(path)
+	} else {
+		viper.AddConfigPath("configs")
+		viper.SetConfigName("config")
+	}
+
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if path != "" || !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+	}
+
+	config, err := Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Parse func for convert current viper settings to typed config.
+// It fails on malformed values only, see Validate for required ones.
+func Parse() (*Config, error) {
+	p := &parser{}
+
+	config := &Config{
+		Server: ServerConfig{
+			Port: p.int("port"),
+		},
+		JWT: JWTConfig{
+			SecretKey:          viper.GetString("jwt_secret_key"),
+			SigningAlgorithm:   viper.GetString("jwt_signing_algorithm"),
+			KeysDir:            viper.GetString("jwt_keys_dir"),
+			AccessTokenTTL:     p.duration("jwt_secret_key_expire_minutes_count", time.Minute),
+			RefreshTokenTTL:    p.duration("jwt_refresh_key_expire_hours_count", time.Hour),
+			RevocationCacheTTL: p.duration("jwt_revocation_cache_seconds_count", time.Second),
+			KeyRotation:        p.duration("jwt_key_rotation_hours_count", time.Hour),
+			KeyRetention:       p.duration("jwt_key_retention_hours_count", time.Hour),
+		},
+		DB: DBConfig{
+			Host:                  viper.GetString("db.host"),
+			Port:                  p.int("db.port"),
+			Username:              viper.GetString("db.username"),
+			Password:              viper.GetString("db.password"),
+			DBName:                viper.GetString("db.dbname"),
+			SSLMode:               viper.GetString("db.sslmode"),
+			MaxConnections:        p.int("db.max_connections"),
+			MaxIdleConnections:    p.int("db.max_idle_connections"),
+			MaxConnectionLifetime: p.duration("db.max_lifetime_connections", time.Minute),
+			ReadTimeout:           p.duration("db.read_timeout_seconds_count", time.Second),
+			WriteTimeout:          p.duration("db.write_timeout_seconds_count", time.Second),
+			TransactionRetries:    p.int("db.transaction_retries_count"),
+			AutoMigrate:           p.bool("db.auto_migrate"),
+		},
+		Cookie: CookieConfig{
+			Enabled:           p.bool("cookie_mode.enabled"),
+			AccessCookieName:  viper.GetString("cookie_mode.access_cookie_name"),
+			CSRFCookieName:    viper.GetString("cookie_mode.csrf_cookie_name"),
+			RefreshCookieName: viper.GetString("cookie_mode.refresh_cookie_name"),
+			Domain:            viper.GetString("cookie_mode.domain"),
+			Secure:            p.bool("cookie_mode.secure"),
+			SameSite:          viper.GetString("cookie_mode.same_site"),
+		},
+		Hasher: HasherConfig{
+			Algorithm:           viper.GetString("password.hasher"),
+			Argon2idMemory:      uint32(p.intMax("password.argon2id.memory", math.MaxInt32)),
+			Argon2idIterations:  uint32(p.intMax("password.argon2id.iterations", math.MaxInt32)),
+			Argon2idParallelism: uint8(p.intMax("password.argon2id.parallelism", 255)),
+			BcryptCost:          p.int("password.bcrypt.cost"),
+			LegacyPlaintext:     p.bool("password.legacy_plaintext"),
+		},
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:            p.int("password_policy.min_length"),
+			MaxLength:            p.int("password_policy.max_length"),
+			RequireLowercase:     p.bool("password_policy.require_lowercase"),
+			RequireUppercase:     p.bool("password_policy.require_uppercase"),
+			RequireDigit:         p.bool("password_policy.require_digit"),
+			RequireSymbol:        p.bool("password_policy.require_symbol"),
+			DisallowPersonalInfo: p.bool("password_policy.disallow_personal_info"),
+			BreachedHashesFile:   viper.GetString("password_policy.breached_hashes_file"),
+		},
+		Mail: MailConfig{
+			Transport: viper.GetString("mail.transport"),
+			From:      viper.GetString("mail.from"),
+			LogFile:   viper.GetString("mail.log_file"),
+			SMTP: SMTPConfig{
+				Host:     viper.GetString("mail.smtp.host"),
+				Port:     p.int("mail.smtp.port"),
+				Username: viper.GetString("mail.smtp.username"),
+				Password: viper.GetString("mail.smtp.password"),
+			},
+		},
+		EmailVerification: EmailVerificationConfig{
+			URL:            viper.GetString("email_verification.url"),
+			TTL:            p.duration("email_verification.expire_hours_count", time.Hour),
+			ResendInterval: p.duration("email_verification.resend_interval_seconds_count", time.Second),
+		},
+		EmailChange: EmailChangeConfig{
+			URL: viper.GetString("email_change.url"),
+			TTL: p.duration("email_change.expire_hours_count", time.Hour),
+		},
+		PasswordReset: PasswordResetConfig{
+			URL:            viper.GetString("password_reset.url"),
+			TTL:            p.duration("password_reset.expire_minutes_count", time.Minute),
+			ResendInterval: p.duration("password_reset.resend_interval_seconds_count", time.Second),
+			SendTimeout:    p.duration("password_reset.send_timeout_seconds_count", time.Second),
+			Workers:        p.int("password_reset.workers_count"),
+			QueueSize:      p.int("password_reset.queue_size_count"),
+		},
+		MFA: MFAConfig{
+			Issuer:          viper.GetString("mfa.issuer"),
+			PendingTokenTTL: p.duration("mfa.pending_token_expire_minutes_count", time.Minute),
+			SkewSteps:       p.int("mfa.skew_steps_count"),
+		},
+		Impersonation: ImpersonationConfig{
+			TTL: p.duration("impersonation.expire_minutes_count", time.Minute),
+		},
+		Throttle: ThrottleConfig{
+			Window:                  p.duration("sign_in_throttle.window_minutes_count", time.Minute),
+			BackoffBase:             p.duration("sign_in_throttle.backoff_base_seconds_count", time.Second),
+			BackoffMax:              p.duration("sign_in_throttle.backoff_max_seconds_count", time.Second),
+			AccountFreeAttempts:     p.int("sign_in_throttle.account_free_attempts_count"),
+			AccountLockoutThreshold: p.int("sign_in_throttle.account_lockout_threshold_count"),
+			AccountLockout:          p.duration("sign_in_throttle.account_lockout_minutes_count", time.Minute),
+			IPFreeAttempts:          p.int("sign_in_throttle.ip_free_attempts_count"),
+		},
+		OIDC: OIDCConfig{
+			RedirectBaseURL: viper.GetString("oidc.redirect_base_url"),
+			Providers:       map[string]OIDCProviderConfig{},
+		},
+	}
+
+	// Providers are keyed by their names, e.g. "oidc.providers.google.issuer".
+	for name := range viper.GetStringMap("oidc.providers") {
+		key := "oidc.providers." + name + "."
+
+		config.OIDC.Providers[name] = OIDCProviderConfig{
+			Issuer:       viper.GetString(key + "issuer"),
+			ClientID:     viper.GetString(key + "client_id"),
+			ClientSecret: viper.GetString(key + "client_secret"),
+			Scopes:       strings.Fields(viper.GetString(key + "scopes")),
+		}
+	}
+
+	if len(p.problems) > 0 {
+		return nil, &ValidationError{Problems: p.problems}
+	}
+
+	return config, nil
+}
+
+// Validate method for check required settings and their ranges.
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, problem string) {
+		if !ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "port must be between 1 and 65535")
+
+	switch c.JWT.SigningAlgorithm {
+	case "HS256":
+		check(c.JWT.SecretKey != "", "jwt_secret_key is required for HS256")
+	case "RS256", "EdDSA":
+		check(c.JWT.KeysDir != "", "jwt_keys_dir is required for "+c.JWT.SigningAlgorithm)
+	default:
+		problems = append(problems, "jwt_signing_algorithm must be HS256, RS256 or EdDSA")
+	}
+	check(c.JWT.AccessTokenTTL > 0, "jwt_secret_key_expire_minutes_count must be positive")
+	check(c.JWT.RefreshTokenTTL > 0, "jwt_refresh_key_expire_hours_count must be positive")
+
+	check(c.DB.Host != "", "db.host is required")
+	check(c.DB.Port > 0 && c.DB.Port <= 65535, "db.port must be between 1 and 65535")
+	check(c.DB.Username != "", "db.username is required")
+	check(c.DB.DBName != "", "db.dbname is required")
+	switch c.DB.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, "db.sslmode must be disable, allow, prefer, require, verify-ca or verify-full")
+	}
+	check(c.DB.MaxConnections == 0 || c.DB.MaxIdleConnections <= c.DB.MaxConnections, "db.max_idle_connections must not be greater than db.max_connections")
+
+	switch c.Cookie.SameSite {
+	case "Strict", "Lax":
+	case "None":
+		check(c.Cookie.Secure, "cookie_mode.secure must be true for cookie_mode.same_site None")
+	default:
+		problems = append(problems, "cookie_mode.same_site must be Strict, Lax or None")
+	}
+	if c.Cookie.Enabled {
+		check(c.Cookie.AccessCookieName != "", "cookie_mode.access_cookie_name is required")
+		check(c.Cookie.CSRFCookieName != "", "cookie_mode.csrf_cookie_name is required")
+		check(c.Cookie.RefreshCookieName != "", "cookie_mode.refresh_cookie_name is required")
+		check(c.Cookie.AccessCookieName != c.Cookie.CSRFCookieName, "cookie_mode.csrf_cookie_name must differ from cookie_mode.access_cookie_name")
+		check(c.Cookie.RefreshCookieName != c.Cookie.AccessCookieName && c.Cookie.RefreshCookieName != c.Cookie.CSRFCookieName, "cookie_mode.refresh_cookie_name must differ from other cookie names")
+	}
+
+	switch c.Hasher.Algorithm {
+	case "argon2id", "bcrypt":
+	default:
+		problems = append(problems, "password.hasher must be argon2id or bcrypt")
+	}
+	check(c.Hasher.Argon2idMemory > 0, "password.argon2id.memory must be positive")
+	check(c.Hasher.Argon2idIterations > 0, "password.argon2id.iterations must be positive")
+	check(c.Hasher.Argon2idParallelism > 0, "password.argon2id.parallelism must be positive")
+	check(c.Hasher.BcryptCost >= 4 && c.Hasher.BcryptCost <= 31, "password.bcrypt.cost must be between 4 and 31")
+
+	check(c.PasswordPolicy.MinLength > 0, "password_policy.min_length must be positive")
+	check(c.PasswordPolicy.MaxLength == 0 || c.PasswordPolicy.MaxLength >= c.PasswordPolicy.MinLength, "password_policy.max_length must not be less than password_policy.min_length")
+
+	switch c.Mail.Transport {
+	case "log":
+	case "smtp":
+		check(c.Mail.SMTP.Host != "", "mail.smtp.host is required for smtp")
+		check(c.Mail.SMTP.Port > 0 && c.Mail.SMTP.Port <= 65535, "mail.smtp.port must be between 1 and 65535")
+	default:
+		problems = append(problems, "mail.transport must be smtp or log")
+	}
+	check(c.Mail.From != "", "mail.from is required")
+
+	check(c.EmailVerification.TTL > 0, "email_verification.expire_hours_count must be positive")
+	check(c.EmailChange.TTL > 0, "email_change.expire_hours_count must be positive")
+	check(c.PasswordReset.TTL > 0, "password_reset.expire_minutes_count must be positive")
+	check(c.PasswordReset.SendTimeout > 0, "password_reset.send_timeout_seconds_count must be positive")
+	check(c.PasswordReset.Workers > 0, "password_reset.workers_count must be positive")
+	check(c.PasswordReset.QueueSize >= 0, "password_reset.queue_size_count must not be negative")
+	check(c.MFA.PendingTokenTTL > 0, "mfa.pending_token_expire_minutes_count must be positive")
+	check(c.Impersonation.TTL > 0, "impersonation.expire_minutes_count must be positive")
+
+	check(c.Throttle.Window > 0, "sign_in_throttle.window_minutes_count must be positive")
+	check(c.Throttle.BackoffBase <= c.Throttle.BackoffMax, "sign_in_throttle.backoff_base_seconds_count must not be greater than sign_in_throttle.backoff_max_seconds_count")
+	check(c.Throttle.AccountLockoutThreshold == 0 || c.Throttle.AccountLockout > 0, "sign_in_throttle.account_lockout_minutes_count must be positive, if lockout is enabled")
+
+	for name, provider := range c.OIDC.Providers {
+		check(provider.Issuer != "", "oidc.providers."+name+".issuer is required")
+		check(provider.ClientID != "", "oidc.providers."+name+".client_id is required")
+	}
+	check(len(c.OIDC.Providers) == 0 || c.OIDC.RedirectBaseURL != "", "oidc.redirect_base_url is required for providers")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// parser struct to collect problems of malformed values.
+type parser struct {
+	problems []string
+}
+
+// int method for read non-negative integer by key, empty value is zero.
+func (p *parser) int(key string) int {
+	value := strings.TrimSpace(viper.GetString(key))
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		p.problems = append(p.problems, fmt.Sprintf("%s must be a non-negative integer, got %q", key, value))
+		return 0
+	}
+
+	return n
+}
+
+// intMax method for read integer, which is not greater than max.
+func (p *parser) intMax(key string, max int) int {
+	n := p.int(key)
+	if n > max {
+		p.problems = append(p.problems, fmt.Sprintf("%s must not be greater than %d, got %d", key, max, n))
+		return 0
+	}
+
+	return n
+}
+
+// duration method for read count of the given units by key.
+func (p *parser) duration(key string, unit time.Duration) time.Duration {
+	return time.Duration(p.int(key)) * unit
+}
+
+// bool method for read boolean by key, empty value is false.
+func (p *parser) bool(key string) bool {
+	value := strings.TrimSpace(viper.GetString(key))
+	if value == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		p.problems = append(p.problems, fmt.Sprintf("%s must be true or false, got %q", key, value))
+		return false
+	}
+
+	return b
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultConfig *Config
+)
+
+// Default func returns config shared by the whole app.
+// It is parsed from defaults and environment on first use, if it was not set on start.
+// Malformed values are a programming or deployment error, so it panics on them.
+func Default() *Config {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultConfig == nil {
+		for key, value := range defaults {
+			viper.SetDefault(key, value)
+		}
+
+		config, err := Parse()
+		if err != nil {
+			panic(err)
+		}
+		defaultConfig = config
+	}
+
+	return defaultConfig
+}
+
+// SetDefault func replaces config shared by the whole app, nil resets it.
+func SetDefault(c *Config) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultConfig = c
+}
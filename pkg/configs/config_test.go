@@ -0,0 +1,242 @@
+package configs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig func writes .yml file with the given content to temporary directory.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	defer viper.Reset()
+
+	path := writeConfig(t, `
+port: "9090"
+jwt_secret_key: "from-file"
+jwt_secret_key_expire_minutes_count: "15"
+db:
+  host: "db.local"
+  password: "from-file"
+  max_connections: "20"
+  auto_migrate: "true"
+mail:
+  transport: "smtp"
+  smtp:
+    host: "smtp.local"
+    port: "587"
+oidc:
+  redirect_base_url: "http://localhost/api/v1/oidc/"
+  providers:
+    google:
+      issuer: "https://accounts.google.com"
+      client_id: "houser"
+      scopes: "openid email"
+`)
+
+	// Environment overrides the file.
+	t.Setenv("HOUSER_DB_PASSWORD", "from-env")
+	t.Setenv("HOUSER_DB_READ_TIMEOUT_SECONDS_COUNT", "2")
+
+	config, err := Load(path)
+	require.NoError(t, err)
+
+	// Values of the file.
+	assert.Equal(t, 9090, config.Server.Port)
+	assert.Equal(t, "from-file", config.JWT.SecretKey)
+	assert.Equal(t, 15*time.Minute, config.JWT.AccessTokenTTL)
+	assert.Equal(t, "db.local", config.DB.Host)
+	assert.Equal(t, 20, config.DB.MaxConnections)
+	assert.True(t, config.DB.AutoMigrate)
+
+	// Values of environment.
+	assert.Equal(t, "from-env", config.DB.Password)
+	assert.Equal(t, 2*time.Second, config.DB.ReadTimeout)
+
+	// Defaults for missing values.
+	assert.Equal(t, "HS256", config.JWT.SigningAlgorithm)
+	assert.Equal(t, 720*time.Hour, config.JWT.RefreshTokenTTL)
+	assert.Equal(t, 5432, config.DB.Port)
+	assert.Equal(t, "houser_db", config.DB.DBName)
+	assert.Equal(t, 10*time.Second, config.DB.WriteTimeout)
+	assert.Equal(t, 3, config.DB.TransactionRetries)
+
+	// Sections of other packages.
+	assert.Equal(t, "smtp", config.Mail.Transport)
+	assert.Equal(t, 587, config.Mail.SMTP.Port)
+	assert.Equal(t, "Houser <no-reply@houser.local>", config.Mail.From)
+	assert.Equal(t, OIDCProviderConfig{Issuer: "https://accounts.google.com", ClientID: "houser", Scopes: []string{"openid", "email"}}, config.OIDC.Providers["google"])
+	assert.Equal(t, CookieConfig{AccessCookieName: "houser_access", CSRFCookieName: "houser_csrf", RefreshCookieName: "houser_refresh", Secure: true, SameSite: "Strict"}, config.Cookie)
+	assert.Equal(t, HasherConfig{Algorithm: "argon2id", Argon2idMemory: 65536, Argon2idIterations: 3, Argon2idParallelism: 2, BcryptCost: 10}, config.Hasher)
+	assert.Equal(t, 8, config.PasswordPolicy.MinLength)
+	assert.True(t, config.PasswordPolicy.DisallowPersonalInfo)
+	assert.Equal(t, 24*time.Hour, config.EmailVerification.TTL)
+	assert.Equal(t, time.Minute, config.EmailVerification.ResendInterval)
+	assert.Equal(t, 30*time.Minute, config.PasswordReset.TTL)
+	assert.Equal(t, 10*time.Second, config.PasswordReset.SendTimeout)
+	assert.Equal(t, 2, config.PasswordReset.Workers)
+	assert.Equal(t, 5*time.Minute, config.MFA.PendingTokenTTL)
+	assert.Equal(t, 1, config.MFA.SkewSteps)
+	assert.Equal(t, 15*time.Minute, config.Impersonation.TTL)
+	assert.Equal(t, 10, config.Throttle.AccountLockoutThreshold)
+	assert.Equal(t, 5*time.Minute, config.Throttle.BackoffMax)
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	defer viper.Reset()
+
+	// Explicit config file must exist.
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.Error(t, err)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		description string
+		content     string
+		problems    []string
+	}{
+		{
+			"malformed values",
+			`
+jwt_secret_key: "secret"
+jwt_secret_key_expire_minutes_count: "an hour"
+mfa:
+  skew_steps_count: "-1"
+password:
+  argon2id:
+    parallelism: "256"
+cookie_mode:
+  enabled: "on"
+db:
+  max_connections: "many"
+  auto_migrate: "yes please"
+`,
+			[]string{
+				`jwt_secret_key_expire_minutes_count must be a non-negative integer, got "an hour"`,
+				`mfa.skew_steps_count must be a non-negative integer, got "-1"`,
+				"password.argon2id.parallelism must not be greater than 255, got 256",
+				`cookie_mode.enabled must be true or false, got "on"`,
+				`db.max_connections must be a non-negative integer, got "many"`,
+				`db.auto_migrate must be true or false, got "yes please"`,
+			},
+		},
+		{
+			"missing and out of range values",
+			`
+port: "70000"
+jwt_secret_key: ""
+jwt_refresh_key_expire_hours_count: "0"
+db:
+  dbname: ""
+  sslmode: "sometimes"
+  max_connections: "5"
+  max_idle_connections: "10"
+`,
+			[]string{
+				"port must be between 1 and 65535",
+				"jwt_secret_key is required for HS256",
+				"jwt_refresh_key_expire_hours_count must be positive",
+				"db.dbname is required",
+				"db.sslmode must be disable, allow, prefer, require, verify-ca or verify-full",
+				"db.max_idle_connections must not be greater than db.max_connections",
+			},
+		},
+		{
+			"invalid sections of other packages",
+			`
+jwt_secret_key: "secret"
+cookie_mode:
+  secure: "false"
+  same_site: "None"
+password:
+  hasher: "md5"
+  bcrypt:
+    cost: "3"
+password_policy:
+  min_length: "12"
+  max_length: "10"
+mail:
+  transport: "smtp"
+  smtp:
+    host: ""
+password_reset:
+  expire_minutes_count: "0"
+  workers_count: "0"
+sign_in_throttle:
+  backoff_base_seconds_count: "600"
+oidc:
+  providers:
+    google:
+      client_id: "houser"
+`,
+			[]string{
+				"cookie_mode.secure must be true for cookie_mode.same_site None",
+				"password.hasher must be argon2id or bcrypt",
+				"password.bcrypt.cost must be between 4 and 31",
+				"password_policy.max_length must not be less than password_policy.min_length",
+				"mail.smtp.host is required for smtp",
+				"password_reset.expire_minutes_count must be positive",
+				"password_reset.workers_count must be positive",
+				"sign_in_throttle.backoff_base_seconds_count must not be greater than sign_in_throttle.backoff_max_seconds_count",
+				"oidc.providers.google.issuer is required",
+				"oidc.redirect_base_url is required for providers",
+			},
+		},
+		{
+			"unknown signing algorithm",
+			`
+jwt_signing_algorithm: "none"
+`,
+			[]string{"jwt_signing_algorithm must be HS256, RS256 or EdDSA"},
+		},
+	}
+
+	for _, test := range tests {
+		viper.Reset()
+
+		_, err := Load(writeConfig(t, test.content))
+
+		var validationErr *ValidationError
+		if assert.True(t, errors.As(err, &validationErr), test.description) {
+			assert.ElementsMatch(t, test.problems, validationErr.Problems, test.description)
+		}
+	}
+
+	viper.Reset()
+}
+
+func TestDefault(t *testing.T) {
+	defer viper.Reset()
+	defer SetDefault(nil)
+
+	// Defaults are used without .yml file.
+	SetDefault(nil)
+	assert.Equal(t, 8080, Default().Server.Port)
+	assert.Equal(t, 15*time.Minute, Default().Impersonation.TTL)
+
+	// Malformed value is not replaced by zero silently.
+	SetDefault(nil)
+	viper.Set("mfa.skew_steps_count", "two")
+	assert.Panics(t, func() { Default() })
+}
+
+func TestDSN(t *testing.T) {
+	config := DBConfig{Host: "localhost", Port: 5432, Username: "postgres", Password: "secret", DBName: "houser_db", SSLMode: "disable"}
+
+	assert.Equal(t, "host=localhost port=5432 user=postgres dbname=houser_db sslmode=disable password=secret", config.DSN())
+}
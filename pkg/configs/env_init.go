@@ -1,24 +1,28 @@
 package configs
 
 import (
+	"errors"
+	"io/fs"
+
 	"github.com/popeskul/houser/pkg/env"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
-func EnvConfigs() {
+// EnvConfigs func for load .env and .yml files once on start, see Load.
+// The loaded config becomes default for the whole app.
+func EnvConfigs(path string) error {
 	logrus.SetFormatter(new(logrus.JSONFormatter))
 
-	viper.AddConfigPath("configs")
-	viper.SetConfigName("config")
-
-	err := viper.ReadInConfig()
-	if err != nil {
-		logrus.Error(err)
+	// Variables of .env file are optional, they do not override environment.
+	if err := env.InitEnv(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
 	}
 
-	err = env.InitEnv()
+	config, err := Load(path)
 	if err != nil {
-		logrus.Error(err)
+		return err
 	}
+	SetDefault(config)
+
+	return nil
 }
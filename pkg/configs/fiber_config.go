@@ -1,6 +1,8 @@
 package configs
 
 import (
+	"encoding/json"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -8,5 +10,10 @@ import (
 // See: https://docs.gofiber.io/api/fiber#config
 func FiberConfig() fiber.Config {
 	// Return Fiber configuration.
-	return fiber.Config{}
+	return fiber.Config{
+		// Use the standard library JSON, bundled go-json faults on map responses
+		// with newer Go runtimes.
+		JSONEncoder: json.Marshal,
+		JSONDecoder: json.Unmarshal,
+	}
 }
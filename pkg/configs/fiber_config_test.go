@@ -0,0 +1,46 @@
+package configs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiberConfigUsesStandardJSON(t *testing.T) {
+	config := FiberConfig()
+
+	assert.Equal(t, reflect.ValueOf(json.Marshal).Pointer(), reflect.ValueOf(config.JSONEncoder).Pointer())
+	assert.Equal(t, reflect.ValueOf(json.Unmarshal).Pointer(), reflect.ValueOf(config.JSONDecoder).Pointer())
+}
+
+func TestFiberConfigJSON(t *testing.T) {
+	// Define a new Fiber app, which responds with the parsed body, like controllers do.
+	app := fiber.New(FiberConfig())
+	app.Post("/", func(c *fiber.Ctx) error {
+		input := map[string]interface{}{}
+		if err := c.BodyParser(&input); err != nil {
+			return err
+		}
+
+		return c.JSON(fiber.Map{"error": false, "msg": nil, "input": input})
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "house"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"error": false, "msg": null, "input": {"name": "house"}}`, string(body))
+}
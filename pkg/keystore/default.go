@@ -0,0 +1,50 @@
+package keystore
+
+import (
+	"sync"
+
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore *KeyStore
+)
+
+// Load func for create a new key store from config.
+func Load() (*KeyStore, error) {
+	config := configs.Default().JWT
+
+	algorithm := config.SigningAlgorithm
+	if algorithm == "" {
+		algorithm = HS256
+	}
+
+	return New(algorithm, config.KeysDir, []byte(config.SecretKey), config.KeyRotation, config.KeyRetention)
+}
+
+// Default func returns the key store shared by the whole app.
+// It is loaded on first use, if it was not set on startup.
+func Default() *KeyStore {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultStore == nil {
+		store, err := Load()
+		if err != nil {
+			panic(err)
+		}
+
+		defaultStore = store
+	}
+
+	return defaultStore
+}
+
+// SetDefault func replaces the key store shared by the whole app.
+func SetDefault(s *KeyStore) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultStore = s
+}
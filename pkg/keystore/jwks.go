@@ -0,0 +1,120 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JWK struct to describe one public key in JSON Web Key format.
+// See: https://datatracker.ietf.org/doc/html/rfc7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS struct to describe JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS method returns public keys valid for verification.
+// Shared HS256 secrets are never published.
+func (s *KeyStore) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+
+	for _, key := range s.Keys() {
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return set
+}
+
+// PublicKey method parses public key of RSA, EC or Ed25519 JWK,
+// e.g. to verify tokens of other issuers.
+func (k JWK) PublicKey() (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.KeyType {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
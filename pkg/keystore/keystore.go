@@ -0,0 +1,356 @@
+package keystore
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// ErrUnknownKey is returned for tokens signed by a key which is not known or already retired.
+var ErrUnknownKey = errors.New("token is signed with an unknown key")
+
+// Key struct to describe one signing key.
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   interface{} // used to sign tokens
+	Public    interface{} // used to verify tokens
+	CreatedAt time.Time
+	RetiredAt time.Time // zero for the current signing key
+}
+
+// KeyStore struct to describe signing keys with rotation.
+// With HS256 the store holds one shared secret. With RS256 and EdDSA the
+// store keeps PKCS #8 keys in a directory, the newest key signs new tokens
+// and older keys stay valid for verification during the retention period.
+type KeyStore struct {
+	algorithm      string
+	dir            string
+	rotateInterval time.Duration
+	retention      time.Duration
+
+	mu      sync.RWMutex
+	current *Key
+	keys    map[string]*Key
+}
+
+// New func for create a new key store.
+// For HS256 the secret is used, for other algorithms keys are loaded from dir
+// and the first key is generated, if the directory is empty.
+func New(algorithm, dir string, secret []byte, rotateInterval, retention time.Duration) (*KeyStore, error) {
+	s := &KeyStore{
+		algorithm:      algorithm,
+		dir:            dir,
+		rotateInterval: rotateInterval,
+		retention:      retention,
+		keys:           map[string]*Key{},
+	}
+
+	switch algorithm {
+	case HS256:
+		key := &Key{ID: "default", Algorithm: HS256, Private: secret, Public: secret}
+		s.current = key
+		s.keys[key.ID] = key
+
+		return s, nil
+	case RS256, EdDSA:
+		if dir == "" {
+			return nil, fmt.Errorf("keys directory is required for %s", algorithm)
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+		if err := s.Reload(); err != nil {
+			return nil, err
+		}
+		if s.current == nil {
+			if err := s.Rotate(); err != nil {
+				return nil, err
+			}
+		}
+
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+}
+
+// Algorithm method returns the signing algorithm.
+func (s *KeyStore) Algorithm() string {
+	return s.algorithm
+}
+
+// SigningKey method returns the key for signing new tokens.
+func (s *KeyStore) SigningKey() *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.current
+}
+
+// VerificationKey method returns the key by given key ID.
+// Tokens without key ID are only accepted with HS256, they were issued before key IDs.
+func (s *KeyStore) VerificationKey(kid string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" && s.algorithm == HS256 {
+		return s.current, nil
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+// Keys method returns all keys valid for verification, newest first.
+func (s *KeyStore) Keys() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+
+	sortKeys(keys)
+
+	return keys
+}
+
+// Reload method for reading keys from the directory.
+// Other app instances may rotate keys in the same directory.
+func (s *KeyStore) Reload() error {
+	if s.algorithm == HS256 {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*Key, 0, len(files))
+	for _, file := range files {
+		key, err := readKey(file)
+		if err != nil {
+			return err
+		}
+
+		// Keys of other algorithms are left from a previous configuration.
+		if key.Algorithm == s.algorithm {
+			keys = append(keys, key)
+		}
+	}
+
+	s.setKeys(keys, time.Now())
+
+	return nil
+}
+
+// Rotate method for generating a new signing key.
+// The previous key stays valid for verification during the retention period.
+func (s *KeyStore) Rotate() error {
+	if s.algorithm == HS256 {
+		return nil
+	}
+
+	key, err := generateKey(s.algorithm)
+	if err != nil {
+		return err
+	}
+
+	if err := writeKey(filepath.Join(s.dir, key.ID+".pem"), key); err != nil {
+		return err
+	}
+
+	return s.Reload()
+}
+
+// RotateIfDue method for generating a new signing key, if the current one is old enough.
+func (s *KeyStore) RotateIfDue() error {
+	if s.algorithm == HS256 || s.rotateInterval <= 0 {
+		return nil
+	}
+
+	if err := s.Reload(); err != nil {
+		return err
+	}
+
+	current := s.SigningKey()
+	if current != nil && time.Since(current.CreatedAt) < s.rotateInterval {
+		return nil
+	}
+
+	return s.Rotate()
+}
+
+// StartRotation method for checking keys on schedule until stop func is called.
+func (s *KeyStore) StartRotation(every time.Duration, onError func(error)) (stop func()) {
+	ticker := time.NewTicker(every)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.RotateIfDue(); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// setKeys replaces known keys, the newest one becomes the signing key
+// and every other key retires when its successor was created.
+func (s *KeyStore) setKeys(keys []*Key, now time.Time) {
+	sortKeys(keys)
+
+	valid := map[string]*Key{}
+	var current *Key
+
+	for i, key := range keys {
+		if i > 0 {
+			key.RetiredAt = keys[i-1].CreatedAt
+			if s.retention > 0 && now.Sub(key.RetiredAt) > s.retention {
+				continue
+			}
+		} else {
+			current = key
+		}
+
+		valid[key.ID] = key
+	}
+
+	s.mu.Lock()
+	s.current = current
+	s.keys = valid
+	s.mu.Unlock()
+}
+
+func sortKeys(keys []*Key) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID > keys[j].ID
+		}
+
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+}
+
+func generateKey(algorithm string) (*Key, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Key ID starts with creation time, so the files are ordered by age.
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	return &Key{
+		ID:        now.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		Algorithm: algorithm,
+		Private:   private,
+		Public:    private.Public(),
+		CreatedAt: now,
+	}, nil
+}
+
+func writeKey(file string, key *Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	// Write to a temporary file first, other instances may read the directory.
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+func readKey(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", file)
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", file, err)
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(file), ".pem"), Private: private}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm = RS256
+		key.Public = &private.PublicKey
+	case ed25519.PrivateKey:
+		key.Algorithm = EdDSA
+		key.Public = private.Public()
+	default:
+		return nil, fmt.Errorf("key %s has unsupported type %T", file, private)
+	}
+
+	// Generated keys carry creation time in ID, other keys use file time.
+	if createdAt, err := time.Parse("20060102T150405Z", strings.SplitN(key.ID, "-", 2)[0]); err == nil {
+		key.CreatedAt = createdAt
+	} else if info, err := os.Stat(file); err == nil {
+		key.CreatedAt = info.ModTime()
+	}
+
+	return key, nil
+}
@@ -0,0 +1,74 @@
+package keystore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyStoreRotation(t *testing.T) {
+	for _, algorithm := range []string{RS256, EdDSA} {
+		dir := t.TempDir()
+
+		// First key is generated for an empty directory.
+		store, err := New(algorithm, dir, nil, time.Hour, time.Hour)
+		assert.NoError(t, err)
+
+		oldKey := store.SigningKey()
+		oldToken := signToken(t, oldKey)
+
+		// Make sure the next key has a later creation time.
+		time.Sleep(time.Second)
+		assert.NoError(t, store.Rotate())
+
+		newKey := store.SigningKey()
+		assert.NotEqual(t, oldKey.ID, newKey.ID, algorithm)
+
+		// Old tokens are still verified by the retired key.
+		_, err = jwt.Parse(oldToken, func(token *jwt.Token) (interface{}, error) {
+			key, err := store.VerificationKey(token.Header["kid"].(string))
+			if err != nil {
+				return nil, err
+			}
+			return key.Public, nil
+		})
+		assert.NoError(t, err, algorithm)
+
+		// Another instance sharing the directory sees both keys.
+		other, err := New(algorithm, dir, nil, time.Hour, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, newKey.ID, other.SigningKey().ID, algorithm)
+		assert.Len(t, other.JWKS().Keys, 2, algorithm)
+
+		// Retired keys are dropped after the retention period.
+		short, err := New(algorithm, dir, nil, time.Hour, time.Nanosecond)
+		assert.NoError(t, err)
+		_, err = short.VerificationKey(oldKey.ID)
+		assert.ErrorIs(t, err, ErrUnknownKey, algorithm)
+	}
+}
+
+func TestKeyStoreHS256(t *testing.T) {
+	store, err := New(HS256, "", []byte("secret"), time.Hour, time.Hour)
+	assert.NoError(t, err)
+
+	// Tokens issued before key IDs are still verified.
+	key, err := store.VerificationKey("")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), key.Public)
+
+	// Shared secret is never published.
+	assert.Empty(t, store.JWKS().Keys)
+}
+
+func signToken(t *testing.T, key *Key) string {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), jwt.MapClaims{"sub": "test"})
+	token.Header["kid"] = key.ID
+
+	signed, err := token.SignedString(key.Private)
+	assert.NoError(t, err)
+
+	return signed
+}
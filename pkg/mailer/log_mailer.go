@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogMailer struct to describe mail transport, which only writes messages.
+// It is meant for development, messages go to stdout or a file.
+type LogMailer struct {
+	Writer io.Writer
+	From   string
+
+	mu sync.Mutex
+}
+
+// Send method for writing message.
+func (m *LogMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.Writer, "--- %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n",
+		time.Now().Format(time.RFC3339), m.From, msg.To, msg.Subject, msg.Body)
+
+	return err
+}
@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// Names of the supported mail transports.
+const (
+	SMTPTransport = "smtp"
+	LogTransport  = "log"
+)
+
+// Message struct to describe one plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer interface to describe a mail transport.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New func for create a new mailer from .yml file.
+func New() (Mailer, error) {
+	config := configs.Default().Mail
+	from := config.From
+
+	switch transport := config.Transport; transport {
+	case SMTPTransport:
+		return &SMTPMailer{
+			Host:     config.SMTP.Host,
+			Port:     strconv.Itoa(config.SMTP.Port),
+			Username: config.SMTP.Username,
+			Password: config.SMTP.Password,
+			From:     from,
+		}, nil
+	case LogTransport, "":
+		file := config.LogFile
+		if file == "" {
+			return &LogMailer{Writer: os.Stdout, From: from}, nil
+		}
+
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LogMailer{Writer: f, From: from}, nil
+	default:
+		return nil, fmt.Errorf("unsupported mail transport %q", transport)
+	}
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultMailer Mailer
+)
+
+// Default func returns the mailer shared by the whole app.
+// It is created on first use, if it was not set on startup.
+func Default() Mailer {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultMailer == nil {
+		m, err := New()
+		if err != nil {
+			panic(err)
+		}
+
+		defaultMailer = m
+	}
+
+	return defaultMailer
+}
+
+// SetDefault func replaces the mailer shared by the whole app.
+func SetDefault(m Mailer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultMailer = m
+}
@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPMailer(t *testing.T) {
+	// Start a local SMTP stand-in, which accepts one message.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go serveOneMessage(listener, received)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	m := &SMTPMailer{Host: host, Port: port, From: "Houser <no-reply@houser.local>"}
+
+	err = m.Send(Message{To: "user@mail.com", Subject: "Verify your email", Body: "Hello"})
+	assert.NoError(t, err)
+
+	data := <-received
+	assert.Contains(t, data, "To: <user@mail.com>")
+	assert.Contains(t, data, "Subject: Verify your email")
+	assert.Contains(t, data, "Hello")
+}
+
+func serveOneMessage(listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 end data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+
+			received <- data.String()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/revocation"
+)
+
+// APIKeyHeader is the request header with personal API key.
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrNoCredentials is returned by authenticator, if request has no credentials of its kind.
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidAPIKey is returned for unknown, expired and revoked API keys.
+	ErrInvalidAPIKey = errors.New("unauthorized, API key is invalid")
+
+	// ErrAuthUnavailable is wrapped by errors of backing stores, credentials can not be trusted without them.
+	ErrAuthUnavailable = errors.New("authentication is unavailable")
+)
+
+// Authenticator func verifies one kind of credentials and returns identity of the request.
+type Authenticator func(c *fiber.Ctx) (*utils.TokenMetadata, error)
+
+// APIKeyLookup func finds identity by hash of API key for the request.
+type APIKeyLookup func(c *fiber.Ctx, hash string) (*utils.TokenMetadata, error)
+
+// Authenticate func for specify routes, which need one of the given credentials.
+// Authenticators are tried in order, the first one, which finds its credentials, decides.
+func Authenticate(authenticators ...Authenticator) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		for _, authenticate := range authenticators {
+			tokenMetadata, err := authenticate(c)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			if err != nil {
+				return jwtError(c, err)
+			}
+
+			// Keep identity for permission middleware and controllers.
+			utils.SetTokenMetadata(c, tokenMetadata)
+
+			// Requests on behalf of other users are not served without audit trail.
+			if tokenMetadata.Impersonating() {
+				if err := recordImpersonation(c, tokenMetadata, models.ImpersonatedRequest); err != nil {
+					return jwtError(c, fmt.Errorf("%w: %v", ErrAuthUnavailable, err))
+				}
+			}
+
+			return c.Next()
+		}
+
+		return jwtError(c, utils.ErrMissingToken)
+	}
+}
+
+// BearerAuthenticator func for authenticate request with JWT from "Authorization" header,
+// or from access cookie in cookie mode.
+// Tokens are verified with the key from keystore by "kid" header,
+// and revoked tokens are rejected.
+func BearerAuthenticator() Authenticator {
+	return func(c *fiber.Ctx) (*utils.TokenMetadata, error) {
+		// Get tokenMetadata from JWT.
+		tokenMetadata, err := utils.ExtractTokenMetadata(c)
+		if errors.Is(err, utils.ErrMissingToken) {
+			return nil, ErrNoCredentials
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Cookies are sent by browsers with any request, so state changes need double-submitted CSRF token.
+		if utils.TokenFromCookie(c) && !utils.SafeMethod(c.Method()) && !utils.ValidCSRFToken(c) {
+			return nil, utils.ErrInvalidCSRFToken
+		}
+
+		// Checking, if token was revoked by sign out.
+		revoked, err := revocation.Default().IsRevoked(c.UserContext(), tokenMetadata.ID, tokenMetadata.UserId, tokenMetadata.IssuedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+		}
+		if revoked {
+			return nil, errors.New("unauthorized, token was revoked")
+		}
+
+		// Checking, if session of the token was terminated.
+		if tokenMetadata.SessionID != uuid.Nil {
+			revoked, err := revocation.Default().IsSessionRevoked(c.UserContext(), tokenMetadata.SessionID, tokenMetadata.UserId)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+			}
+			if revoked {
+				return nil, errors.New("unauthorized, session was terminated")
+			}
+		}
+
+		return tokenMetadata, nil
+	}
+}
+
+// APIKeyAuthenticator func for authenticate request with personal API key from "X-API-Key" header.
+func APIKeyAuthenticator(lookup APIKeyLookup) Authenticator {
+	return func(c *fiber.Ctx) (*utils.TokenMetadata, error) {
+		key := c.Get(APIKeyHeader)
+		if key == "" {
+			return nil, ErrNoCredentials
+		}
+
+		return lookup(c, utils.HashToken(key))
+	}
+}
+
+// DatabaseAPIKeyLookup func finds API key and its owner in the database.
+func DatabaseAPIKeyLookup(c *fiber.Ctx, hash string) (*utils.TokenMetadata, error) {
+	// Get API keys and users repositories.
+	keys, err := container.APIKeys(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+	users, err := container.Users(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+
+	// Get API key by its hash.
+	key, err := keys.GetAPIKeyByHash(c.UserContext(), hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+
+	// Checking, if API key is revoked or expired.
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Get owner of API key, the role may be changed since the key was created.
+	user, err := users.GetUserById(c.UserContext(), key.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+
+	// Usage time is informational, failure to save it does not deny the request.
+	_ = keys.TouchAPIKey(c.UserContext(), key.ID)
+
+	expires := int64(math.MaxInt64)
+	if key.ExpiresAt != nil {
+		expires = key.ExpiresAt.Unix()
+	}
+
+	scopes := make([]rbac.Permission, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, rbac.Permission(scope))
+	}
+
+	return &utils.TokenMetadata{
+		ID:       key.ID,
+		IssuedAt: key.CreatedAt,
+		Expires:  expires,
+		UserId:   user.ID,
+		Role:     rbac.Role(user.Role),
+		APIKeyID: key.ID,
+		Scopes:   scopes,
+	}, nil
+}
+
+// RequireAccessToken func for specify routes, which manage the account itself,
+// so they can not be used with API keys. It must be used after JWTProtected.
+func RequireAccessToken() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		// Get tokenMetadata of the request.
+		tokenMetadata, err := utils.ExtractTokenMetadata(c)
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		// Checking, if request is authenticated with API key.
+		if tokenMetadata.APIKeyID != uuid.Nil {
+			// Return status 403 and API key error.
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "forbidden, API keys can not be used for this route",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	// Keep one API key of the owner with read-only scope.
+	readKey := "hsr_00000000_read"
+	lookup := func(c *fiber.Ctx, hash string) (*utils.TokenMetadata, error) {
+		switch hash {
+		case utils.HashToken(readKey):
+			return &utils.TokenMetadata{
+				ID:       uuid.New(),
+				UserId:   uuid.New(),
+				Role:     rbac.RoleOwner,
+				APIKeyID: uuid.New(),
+				Scopes:   []rbac.Permission{rbac.HousesRead},
+			}, nil
+		case utils.HashToken("hsr_00000000_down"):
+			return nil, ErrAuthUnavailable
+		default:
+			return nil, ErrInvalidAPIKey
+		}
+	}
+
+	// Define a new Fiber app with routes behind the authenticator chain.
+	app := fiber.New(configs.FiberConfig())
+	auth := Authenticate(APIKeyAuthenticator(lookup))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/houses", auth, RequirePermission(rbac.HousesRead), ok)
+	app.Post("/house", auth, RequirePermission(rbac.HousesWrite), ok)
+	app.Post("/sign-out", auth, RequireAccessToken(), ok)
+
+	tests := []struct {
+		description  string
+		method       string
+		route        string
+		key          string
+		expectedCode int
+	}{
+		{"read with scope", "GET", "/houses", readKey, fiber.StatusOK},
+		{"write without scope", "POST", "/house", readKey, fiber.StatusForbidden},
+		{"account route with API key", "POST", "/sign-out", readKey, fiber.StatusForbidden},
+		{"unknown key", "GET", "/houses", "hsr_00000000_unknown", fiber.StatusUnauthorized},
+		{"store is down", "GET", "/houses", "hsr_00000000_down", fiber.StatusServiceUnavailable},
+		{"no credentials", "GET", "/houses", "", fiber.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.route, nil)
+		if test.key != "" {
+			req.Header.Set(APIKeyHeader, test.key)
+		}
+
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err, test.description)
+		assert.Equal(t, test.expectedCode, resp.StatusCode, test.description)
+	}
+}
+
+func TestAuthenticateFallsThrough(t *testing.T) {
+	calls := 0
+	none := func(c *fiber.Ctx) (*utils.TokenMetadata, error) {
+		calls++
+		return nil, ErrNoCredentials
+	}
+	failed := func(c *fiber.Ctx) (*utils.TokenMetadata, error) {
+		calls++
+		return nil, errors.New("unauthorized")
+	}
+
+	app := fiber.New(configs.FiberConfig())
+	app.Get("/", Authenticate(none, failed, none), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	// The first authenticator, which finds credentials, decides.
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/audit"
+)
+
+// DenyImpersonation func for specify routes, which must be done by the user personally,
+// e.g. password change or deletion. It must be used after JWTProtected.
+func DenyImpersonation() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		// Get tokenMetadata of the request.
+		tokenMetadata, err := utils.ExtractTokenMetadata(c)
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		// Checking, if request is made by admin on behalf of the user.
+		if tokenMetadata.Impersonating() {
+			// Return status 403 and impersonation error.
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "forbidden, this route can not be used while impersonating",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// recordImpersonation func for saving the request of the actor to the audit log.
+func recordImpersonation(c *fiber.Ctx, tokenMetadata *utils.TokenMetadata, action string) error {
+	return audit.Default().Record(c.UserContext(), &models.ImpersonationAuditEntry{
+		ID:        uuid.New(),
+		ActorID:   tokenMetadata.ActorID,
+		UserID:    tokenMetadata.UserId,
+		TokenID:   tokenMetadata.ID,
+		Action:    action,
+		Method:    c.Method(),
+		Path:      c.OriginalURL(),
+		IP:        c.IP(),
+		CreatedAt: time.Now(),
+	})
+}
@@ -1,37 +1,50 @@
 package middleware
 
 import (
-	"github.com/gofiber/fiber/v2"
-	"github.com/spf13/viper"
+	"errors"
 
-	jwtMiddleware "github.com/gofiber/jwt/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/utils"
 )
 
-// JWTProtected func for specify routes group with JWT authentication.
-// See: https://github.com/gofiber/jwt
+// JWTProtected func for specify routes group with authentication.
+// Requests are authenticated with Bearer JWT, access cookie in cookie mode, or with
+// personal API key from "X-API-Key" header, all give the same request identity.
 func JWTProtected() func(*fiber.Ctx) error {
-	// Create config for JWT authentication middleware.
-	config := jwtMiddleware.Config{
-		SigningKey:   []byte(viper.GetString("jwt_secret_key")),
-		ContextKey:   "jwt", // used in private routes
-		ErrorHandler: jwtError,
-	}
-
-	return jwtMiddleware.New(config)
+	return Authenticate(
+		BearerAuthenticator(),
+		APIKeyAuthenticator(DatabaseAPIKeyLookup),
+	)
 }
 
 func jwtError(c *fiber.Ctx, err error) error {
-	// Return status 401 and failed authentication error.
-	if err.Error() == "Missing or malformed JWT" {
+	// Return status 400 and missing token error.
+	if errors.Is(err, utils.ErrMissingToken) {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": true,
 			"msg":   err.Error(),
 		})
 	}
 
+	// Return status 403, cookie was sent without CSRF token.
+	if errors.Is(err, utils.ErrInvalidCSRFToken) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
+	// Return status 503, credentials can not be verified now.
+	if errors.Is(err, ErrAuthUnavailable) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
 	// Return status 401 and failed authentication error.
 	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 		"error": true,
 		"msg":   err.Error(),
 	})
-}
\ No newline at end of file
+}
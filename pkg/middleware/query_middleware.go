@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/app/queries"
+)
+
+// StatusClientClosedRequest is returned, if queries of the request were canceled,
+// e.g. the server shuts down. There is no such status in net/http, it comes from nginx.
+const StatusClientClosedRequest = 499
+
+// QueryContext func gives every request context for database queries.
+// The context is canceled on server shutdown, and the response is replaced
+// with API error, if a query of the request was canceled or timed out.
+func QueryContext() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		// Request context of fasthttp is done, when the server shuts down.
+		ctx := queries.TrackInterruptions(c.Context())
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		switch interruption := queries.Interruption(ctx); {
+		case errors.Is(interruption, context.DeadlineExceeded):
+			// Return status 503 and timeout error.
+			return interruptedResponse(c, fiber.StatusServiceUnavailable, "database query timed out, try again later")
+		case errors.Is(interruption, context.Canceled):
+			// Return status 499 and cancellation error.
+			return interruptedResponse(c, StatusClientClosedRequest, "request was canceled")
+		}
+
+		return err
+	}
+}
+
+// interruptedResponse func replaces whatever the handler answered after the failed query.
+func interruptedResponse(c *fiber.Ctx, status int, msg string) error {
+	// Cookies of the half done request must not reach the client.
+	c.Response().Header.DelAllCookies()
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": true,
+		"msg":   msg,
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/queries"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryContext(t *testing.T) {
+	// Queries are not sent, when context is done, so connection is never opened.
+	db, err := sqlx.Open("postgres", "")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	users := &queries.UserQueries{Executor: db}
+
+	// Define a new Fiber app, which runs query with context made by the given func.
+	newApp := func(queryContext func(ctx context.Context) (context.Context, context.CancelFunc)) *fiber.App {
+		app := fiber.New(configs.FiberConfig())
+		app.Use(QueryContext())
+		app.Get("/", func(c *fiber.Ctx) error {
+			ctx, cancel := queryContext(c.UserContext())
+			defer cancel()
+
+			// Handlers answer 404 on any error of the query.
+			if _, err := users.GetUserById(ctx, uuid.New()); err != nil {
+				return c.SendStatus(fiber.StatusNotFound)
+			}
+
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		return app
+	}
+
+	tests := []struct {
+		description  string
+		queryContext func(ctx context.Context) (context.Context, context.CancelFunc)
+		expectedCode int
+	}{
+		{
+			description: "timed out query",
+			queryContext: func(ctx context.Context) (context.Context, context.CancelFunc) {
+				return context.WithDeadline(ctx, time.Now())
+			},
+			expectedCode: fiber.StatusServiceUnavailable,
+		},
+		{
+			description: "canceled query",
+			queryContext: func(ctx context.Context) (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+
+				return ctx, cancel
+			},
+			expectedCode: StatusClientClosedRequest,
+		},
+	}
+
+	for _, test := range tests {
+		resp, err := newApp(test.queryContext).Test(httptest.NewRequest("GET", "/", nil), -1)
+
+		assert.NoErrorf(t, err, test.description)
+		assert.Equalf(t, test.expectedCode, resp.StatusCode, test.description)
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+)
+
+// RequirePermission func for specify routes, which need the permission.
+// It must be used after JWTProtected.
+func RequirePermission(permission rbac.Permission) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		// Get tokenMetadata from JWT.
+		tokenMetadata, err := utils.ExtractTokenMetadata(c)
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		// Checking, if role of the user has the permission, and API key has the scope.
+		if !tokenMetadata.Can(permission) {
+			// Return status 403 and permission error.
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "forbidden, permission " + string(permission) + " is required",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,336 @@
+// Package oidc implements OpenID Connect relying party with authorization code flow and PKCE.
+// See: https://openid.net/specs/openid-connect-core-1_0.html
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/popeskul/houser/pkg/keystore"
+)
+
+var (
+	// ErrInvalidIDToken is returned, if ID token of the provider can not be trusted.
+	ErrInvalidIDToken = errors.New("ID token is invalid")
+
+	// ErrUnknownProvider is returned for providers, which are not configured.
+	ErrUnknownProvider = errors.New("identity provider is not configured")
+)
+
+// Config struct to describe one identity provider.
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string
+}
+
+// Claims struct to describe identity from verified ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Nonce         string
+}
+
+// discovery struct to describe used fields of provider metadata.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider struct to describe identity provider with lazily loaded metadata and keys.
+type Provider struct {
+	Config
+	Client *http.Client
+
+	mu       sync.Mutex
+	metadata *discovery
+	keys     map[string]interface{}
+}
+
+// NewProvider func for create a new identity provider.
+func NewProvider(config Config) *Provider {
+	return &Provider{
+		Config: config,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// AuthCodeURL method for getting URL of the provider, where the user signs in.
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.ClientID)
+	params.Set("redirect_uri", p.RedirectURL)
+	params.Set("scope", strings.Join(p.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange method for exchange authorization code for verified identity.
+// Caller must compare nonce of the claims with the one sent to the provider.
+func (p *Provider) Exchange(code, codeVerifier string) (*Claims, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+
+	var response struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := p.do(req, &response); err != nil {
+		return nil, err
+	}
+	if response.IDToken == "" {
+		return nil, fmt.Errorf("token response has no ID token: %s", response.Error)
+	}
+
+	return p.Verify(response.IDToken)
+}
+
+// Verify method for verify signature, issuer, audience and expiration of ID token.
+func (p *Provider) Verify(idToken string) (*Claims, error) {
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.Alg() {
+		case "RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA":
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+
+		kid, _ := token.Header["kid"].(string)
+
+		return p.key(kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	if claimString(claims, "iss") != p.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidIDToken)
+	}
+	if !hasAudience(claims["aud"], p.ClientID) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidIDToken)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("%w: token has no expiration", ErrInvalidIDToken)
+	}
+	if claimString(claims, "sub") == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidIDToken)
+	}
+
+	// Some providers send "email_verified" as a string.
+	verified := claims["email_verified"] == true || claims["email_verified"] == "true"
+
+	return &Claims{
+		Subject:       claimString(claims, "sub"),
+		Email:         claimString(claims, "email"),
+		EmailVerified: verified,
+		Name:          claimString(claims, "name"),
+		Nonce:         claimString(claims, "nonce"),
+	}, nil
+}
+
+// discover method for load provider metadata once.
+func (p *Provider) discover() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &discovery{}
+	if err := p.do(req, metadata); err != nil {
+		return nil, err
+	}
+	if metadata.Issuer != p.Issuer {
+		return nil, fmt.Errorf("provider metadata has issuer %q, expected %q", metadata.Issuer, p.Issuer)
+	}
+
+	p.metadata = metadata
+
+	return metadata, nil
+}
+
+// key method for getting public key of the provider by key ID.
+// Keys are reloaded, if the key is unknown, because providers rotate them.
+func (p *Provider) key(kid string) (interface{}, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	metadata, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	set := keystore.JWKS{}
+	if err := p.do(req, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		public, err := jwk.PublicKey()
+		if err != nil {
+			// Skip keys of unsupported types.
+			continue
+		}
+
+		keys[jwk.KeyID] = public
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		// Providers with one key may omit key ID.
+		if kid == "" && len(keys) == 1 {
+			for _, only := range keys {
+				return only, nil
+			}
+		}
+
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	return key, nil
+}
+
+// do method for send request and decode JSON response.
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: unexpected status %d: %s", req.Method, req.URL, resp.StatusCode, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// NewCodeVerifier func for generate a new PKCE code verifier.
+// See: https://datatracker.ietf.org/doc/html/rfc7636
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// CodeChallenge func for getting S256 code challenge of the code verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewState func for generate a new random state or nonce.
+func NewState() (string, error) {
+	return randomString(16)
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func claimString(claims jwt.MapClaims, key string) string {
+	value, _ := claims[key].(string)
+
+	return value
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package oidc
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/popeskul/houser/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	server := oidctest.NewServer("houser", "secret")
+	defer server.Close()
+	server.Identity = oidctest.Identity{Subject: "42", Email: "user@mail.com", EmailVerified: true, Name: "User"}
+
+	provider := NewProvider(Config{
+		Name:         "mock",
+		Issuer:       server.URL,
+		ClientID:     "houser",
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "email"},
+		RedirectURL:  "http://localhost/api/v1/oidc/mock/callback",
+	})
+
+	state, _ := NewState()
+	nonce, _ := NewState()
+	verifier, err := NewCodeVerifier()
+	assert.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(state, nonce, CodeChallenge(verifier))
+	assert.NoError(t, err)
+
+	// The user signs in at the provider and is redirected back with the code.
+	code := authorize(t, authURL, state)
+
+	// The code does not work without the right verifier.
+	_, err = provider.Exchange(code, "wrong")
+	assert.Error(t, err)
+
+	code = authorize(t, authURL, state)
+	claims, err := provider.Exchange(code, verifier)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", claims.Subject)
+	assert.Equal(t, "user@mail.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, nonce, claims.Nonce)
+
+	// Tokens issued for another client are rejected.
+	server.Audience = "other"
+	code = authorize(t, authURL, state)
+	_, err = provider.Exchange(code, verifier)
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func authorize(t *testing.T, authURL, state string) string {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, state, location.Query().Get("state"))
+
+	return location.Query().Get("code")
+}
@@ -0,0 +1,78 @@
+package oidc
+
+import (
+	"sync"
+
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// Registry struct to describe configured identity providers by name.
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry func for create a new registry of the given providers.
+func NewRegistry(providers ...*Provider) *Registry {
+	r := &Registry{providers: map[string]*Provider{}}
+	for _, p := range providers {
+		r.providers[p.Name] = p
+	}
+
+	return r
+}
+
+// Get method for getting provider by name.
+func (r *Registry) Get(name string) (*Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	return p, nil
+}
+
+// Load func for create a new registry from .yml file.
+// Callback URL of every provider is the redirect base URL with "<name>/callback".
+func Load() *Registry {
+	config := configs.Default().OIDC
+
+	var providers []*Provider
+	for name, provider := range config.Providers {
+		providers = append(providers, NewProvider(Config{
+			Name:         name,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			Scopes:       provider.Scopes,
+			RedirectURL:  config.RedirectBaseURL + name + "/callback",
+		}))
+	}
+
+	return NewRegistry(providers...)
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultRegistry *Registry
+)
+
+// Default func returns the registry shared by the whole app.
+// It is loaded on first use, if it was not set on startup.
+func Default() *Registry {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultRegistry == nil {
+		defaultRegistry = Load()
+	}
+
+	return defaultRegistry
+}
+
+// SetDefault func replaces the registry shared by the whole app.
+func SetDefault(r *Registry) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultRegistry = r
+}
@@ -0,0 +1,79 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixLength is the length of hash prefix used for k-anonymity range lookups.
+const prefixLength = 5
+
+// BreachedList struct to describe local list of SHA-1 hashes of breached passwords.
+// Hashes are grouped by 5 characters prefix like in k-anonymity range API of
+// Have I Been Pwned, so only suffixes of one range are compared on lookup.
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+}
+
+// LoadBreachedList func for read list from file.
+// Every line is an uppercase or lowercase SHA-1 hash with optional ":COUNT",
+// e.g. lines of "pwned-passwords-sha1-ordered-by-hash" file.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &BreachedList{ranges: map[string]map[string]struct{}{}}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// Drop breach count, it is not used.
+		if i := strings.IndexByte(text, ':'); i >= 0 {
+			text = text[:i]
+		}
+
+		if len(text) != sha1.Size*2 {
+			return nil, fmt.Errorf("%s:%d: invalid SHA-1 hash", path, line)
+		}
+		if _, err := hex.DecodeString(text); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+
+		list.add(strings.ToUpper(text))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Contains method reports whether password is in the list.
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, ok := l.ranges[hash[:prefixLength]][hash[prefixLength:]]
+
+	return ok
+}
+
+func (l *BreachedList) add(hash string) {
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	if l.ranges[prefix] == nil {
+		l.ranges[prefix] = map[string]struct{}{}
+	}
+	l.ranges[prefix][suffix] = struct{}{}
+}
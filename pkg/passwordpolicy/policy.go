@@ -0,0 +1,183 @@
+// Package passwordpolicy checks new passwords against configurable rules
+// and a list of breached passwords.
+package passwordpolicy
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// PolicyError is returned for passwords, which break the policy.
+// Every violation is a message, which can be shown to the user.
+type PolicyError struct {
+	Violations []string
+}
+
+// Error method for implement error interface.
+func (e *PolicyError) Error() string {
+	return "password " + strings.Join(e.Violations, ", ")
+}
+
+// BcryptMaxBytes is the longest password, which bcrypt hashes completely.
+// Bytes after it are ignored silently, so such passwords are rejected.
+const BcryptMaxBytes = 72
+
+// Policy struct to describe rules for new passwords.
+type Policy struct {
+	MinLength            int
+	MaxLength            int
+	MaxBytes             int // 0 is unlimited, it is set for bcrypt hasher
+	RequireLowercase     bool
+	RequireUppercase     bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	DisallowPersonalInfo bool          // password can not contain email or name of the user
+	Breached             *BreachedList // nil disables breached password check
+}
+
+// Load func for create policy from .yml file.
+func Load() (*Policy, error) {
+	config := configs.Default().PasswordPolicy
+
+	policy := &Policy{
+		MinLength:            config.MinLength,
+		MaxLength:            config.MaxLength,
+		RequireLowercase:     config.RequireLowercase,
+		RequireUppercase:     config.RequireUppercase,
+		RequireDigit:         config.RequireDigit,
+		RequireSymbol:        config.RequireSymbol,
+		DisallowPersonalInfo: config.DisallowPersonalInfo,
+	}
+
+	// Bcrypt hashes only the first bytes of the password.
+	if configs.Default().Hasher.Algorithm == "bcrypt" {
+		policy.MaxBytes = BcryptMaxBytes
+	}
+
+	// Breached password check is optional.
+	if path := config.BreachedHashesFile; path != "" {
+		list, err := LoadBreachedList(path)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = list
+	}
+
+	return policy, nil
+}
+
+// Check method for verify a new password, personal info is email and name of the user.
+// It returns *PolicyError with all violations at once.
+func (p *Policy) Check(password string, personal ...string) error {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, "must be at least "+strconv.Itoa(p.MinLength)+" characters long")
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, "must be at most "+strconv.Itoa(p.MaxLength)+" characters long")
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		violations = append(violations, "must be at most "+strconv.Itoa(p.MaxBytes)+" bytes long")
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireLowercase && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireUppercase && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, personal) {
+		violations = append(violations, "must not contain your email or name")
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, "has appeared in a data breach, choose another one")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// containsPersonalInfo func reports whether password contains email, its local part or name.
+// Too short values are ignored, they would forbid too many passwords.
+func containsPersonalInfo(password string, personal []string) bool {
+	password = strings.ToLower(password)
+
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		candidates := []string{value}
+		if at := strings.Index(value, "@"); at > 0 {
+			candidates = append(candidates, value[:at])
+		}
+		candidates = append(candidates, strings.Fields(value)...)
+
+		for _, candidate := range candidates {
+			if utf8.RuneCountInString(candidate) >= 3 && strings.Contains(password, candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultPolicy *Policy
+)
+
+// Default func returns the policy shared by the whole app.
+func Default() *Policy {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultPolicy == nil {
+		policy, err := Load()
+		if err != nil {
+			panic(err)
+		}
+
+		defaultPolicy = policy
+	}
+
+	return defaultPolicy
+}
+
+// SetDefault func replaces the policy shared by the whole app.
+func SetDefault(p *Policy) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultPolicy = p
+}
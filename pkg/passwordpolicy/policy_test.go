@@ -0,0 +1,101 @@
+package passwordpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := &Policy{
+		MinLength:            8,
+		MaxLength:            16,
+		RequireLowercase:     true,
+		RequireUppercase:     true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		DisallowPersonalInfo: true,
+	}
+
+	tests := []struct {
+		description string
+		password    string
+		violations  int
+	}{
+		{"strong password", "Tr0ub4dor&3x", 0},
+		{"too short", "Ab1!", 1},
+		{"too long", "Ab1!Ab1!Ab1!Ab1!Ab1!", 1},
+		{"without classes", "aaaaaaaaaa", 3},
+		{"contains email local part", "Johnny!2024", 1},
+		{"contains name", "Xsmith!2024", 1},
+	}
+
+	for _, test := range tests {
+		err := policy.Check(test.password, "johnny@mail.com", "Anna Smith")
+		if test.violations == 0 {
+			assert.NoErrorf(t, err, test.description)
+			continue
+		}
+
+		policyErr, ok := err.(*PolicyError)
+		if assert.Truef(t, ok, test.description) {
+			assert.Lenf(t, policyErr.Violations, test.violations, test.description)
+		}
+	}
+}
+
+func TestBreachedList(t *testing.T) {
+	// SHA-1 of "password" with count and SHA-1 of "123456" in lowercase.
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	data := "# test list\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n7c4a8d09ca3762af61e59520943dc26494f8941b\n"
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	list, err := LoadBreachedList(path)
+	assert.NoError(t, err)
+
+	assert.True(t, list.Contains("password"))
+	assert.True(t, list.Contains("123456"))
+	assert.False(t, list.Contains("correct horse battery staple"))
+
+	// Breached password is reported by policy.
+	policy := &Policy{MinLength: 1, Breached: list}
+	assert.Error(t, policy.Check("password"))
+	assert.NoError(t, policy.Check("correct horse battery staple"))
+
+	// Broken lines are not ignored silently.
+	assert.NoError(t, os.WriteFile(path, []byte("not a hash\n"), 0o600))
+	_, err = LoadBreachedList(path)
+	assert.Error(t, err)
+}
+
+func TestLoadLimitsBytesForBcrypt(t *testing.T) {
+	// Bcrypt would ignore bytes after the 72nd one.
+	config := *configs.Default()
+	config.Hasher.Algorithm = "bcrypt"
+	configs.SetDefault(&config)
+	defer configs.SetDefault(nil)
+
+	policy, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, BcryptMaxBytes, policy.MaxBytes)
+
+	long := strings.Repeat("Ab1!", 20)
+	policyErr, ok := policy.Check(long).(*PolicyError)
+	if assert.True(t, ok) {
+		assert.Equal(t, []string{"must be at most 72 bytes long"}, policyErr.Violations)
+	}
+	assert.NoError(t, policy.Check(long[:BcryptMaxBytes]))
+
+	// Argon2id hashes the whole password.
+	config.Hasher.Algorithm = "argon2id"
+
+	policy, err = Load()
+	require.NoError(t, err)
+	assert.Zero(t, policy.MaxBytes)
+	assert.NoError(t, policy.Check(long))
+}
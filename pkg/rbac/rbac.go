@@ -0,0 +1,82 @@
+package rbac
+
+import "github.com/google/uuid"
+
+// Role of the user, it is stored per user and embedded in access tokens.
+type Role string
+
+// Supported roles.
+const (
+	RoleAdmin  Role = "admin"  // manages all users and houses
+	RoleAgent  Role = "agent"  // manages all houses
+	RoleOwner  Role = "owner"  // manages own houses
+	RoleViewer Role = "viewer" // only reads
+)
+
+// Permission to do an action with a resource.
+type Permission string
+
+// Supported permissions.
+const (
+	UsersRead        Permission = "users:read"
+	UsersWrite       Permission = "users:write"
+	UsersDelete      Permission = "users:delete"
+	UsersMFA         Permission = "users:mfa"         // reset second factor of other users
+	UsersImpersonate Permission = "users:impersonate" // act on behalf of other users
+	HousesRead       Permission = "houses:read"
+	HousesWrite      Permission = "houses:write"
+	HousesDelete     Permission = "houses:delete"
+	HousesManage     Permission = "houses:manage" // write and delete houses of other owners
+	ProfileWrite     Permission = "profile:write" // change own profile
+)
+
+// rolePermissions describes what every role is allowed to do.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		UsersRead, UsersWrite, UsersDelete, UsersMFA, UsersImpersonate,
+		HousesRead, HousesWrite, HousesDelete, HousesManage,
+		ProfileWrite,
+	},
+	RoleAgent: {
+		UsersRead,
+		HousesRead, HousesWrite, HousesDelete, HousesManage,
+		ProfileWrite,
+	},
+	RoleOwner: {
+		UsersRead,
+		HousesRead, HousesWrite, HousesDelete,
+		ProfileWrite,
+	},
+	RoleViewer: {
+		UsersRead,
+		HousesRead,
+		ProfileWrite,
+	},
+}
+
+// DefaultRole is given to users on sign up.
+const DefaultRole = RoleOwner
+
+// Valid func reports whether role is known.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+
+	return ok
+}
+
+// Can func reports whether role has the permission.
+func Can(role Role, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanManage func reports whether user with role may change a resource of the owner.
+// Owners always manage their own resources, anyone else needs the given permission.
+func CanManage(role Role, userID, ownerID uuid.UUID, permission Permission) bool {
+	return userID == ownerID || Can(role, permission)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/popeskul/houser/app/controllers"
 	"github.com/popeskul/houser/pkg/middleware"
+	"github.com/popeskul/houser/pkg/rbac"
 )
 
 // PrivateRoutes func for describe group of private routes.
@@ -11,13 +12,56 @@ func PrivateRoutes(a *fiber.App) {
 	// Create routes group.
 	route := a.Group("/api/v1")
 
+	// Short aliases for permission middlewares.
+	can := middleware.RequirePermission
+	account := middleware.RequireAccessToken()
+	personally := middleware.DenyImpersonation()
+
+	// Routes for auth:
+	route.Post("/sign-out", middleware.JWTProtected(), account, controllers.SignOut)                            // logout and revoke token
+	route.Post("/sign-out/all", middleware.JWTProtected(), account, personally, controllers.SignOutEverywhere)  // logout from all devices
+	route.Post("/verify-email/resend", middleware.JWTProtected(), account, controllers.ResendEmailVerification) // resend verification email
+	route.Get("/sign-in-attempts", middleware.JWTProtected(), account, controllers.GetSignInAttempts)           // get own sign in attempts
+
+	// Routes for the current user:
+	route.Get("/me", middleware.JWTProtected(), controllers.GetMe)                                    // get own profile
+	route.Patch("/me", middleware.JWTProtected(), account, personally, controllers.UpdateMe)          // update own profile
+	route.Delete("/me", middleware.JWTProtected(), account, personally, controllers.DeleteMe)         // delete own account
+	route.Get("/me/houses", middleware.JWTProtected(), can(rbac.HousesRead), controllers.GetMyHouses) // get own houses
+
+	// Routes for account:
+	route.Post("/me/password", middleware.JWTProtected(), account, personally, controllers.ChangePassword) // change own password
+	route.Post("/me/email", middleware.JWTProtected(), account, personally, controllers.ChangeEmail)       // request change of own email
+
+	// Routes for sessions:
+	route.Get("/me/sessions", middleware.JWTProtected(), account, controllers.GetSessions)                      // get own active sessions
+	route.Delete("/me/sessions/:id", middleware.JWTProtected(), account, personally, controllers.DeleteSession) // terminate one session
+
+	// Routes for external identities:
+	route.Get("/me/identities/:provider/authorize", middleware.JWTProtected(), account, personally, controllers.LinkIdentity) // start linking identity provider
+
+	// Routes for two-factor authentication:
+	route.Post("/mfa/enroll", middleware.JWTProtected(), account, personally, controllers.EnrollMFA)         // start two-factor enrollment
+	route.Post("/mfa/enroll/verify", middleware.JWTProtected(), account, personally, controllers.ConfirmMFA) // confirm first code and get recovery codes
+
+	// Routes for API keys:
+	route.Post("/api-key", middleware.JWTProtected(), account, personally, controllers.CreateAPIKey)   // create a new API key
+	route.Get("/api-keys", middleware.JWTProtected(), account, controllers.GetAPIKeys)                 // get list of own API keys
+	route.Delete("/api-key", middleware.JWTProtected(), account, personally, controllers.RevokeAPIKey) // revoke API key by ID
+
 	// Routes for /user:
-	route.Post("/user", middleware.JWTProtected(), controllers.CreateUser)   // create a new user
-	route.Put("/user", middleware.JWTProtected(), controllers.UpdateUser)    // update one user by ID
-	route.Delete("/user", middleware.JWTProtected(), controllers.DeleteUser) // delete one user by ID
+	route.Get("/users", middleware.JWTProtected(), can(rbac.UsersRead), controllers.GetUsers)                            // get list of all users
+	route.Post("/user", middleware.JWTProtected(), can(rbac.UsersWrite), controllers.CreateUser)                         // create a new user
+	route.Put("/user", middleware.JWTProtected(), account, personally, can(rbac.ProfileWrite), controllers.UpdateUser)   // update one user by ID
+	route.Delete("/user", middleware.JWTProtected(), account, personally, can(rbac.UsersDelete), controllers.DeleteUser) // delete other user by ID
+	route.Delete("/user/mfa", middleware.JWTProtected(), can(rbac.UsersMFA), controllers.ResetUserMFA)                   // reset two-factor of the user
+	route.Delete("/user/lockout", middleware.JWTProtected(), can(rbac.UsersWrite), controllers.UnlockUser)               // unlock sign in of the user
+
+	// Routes for admin:
+	route.Post("/admin/impersonate/:userId", middleware.JWTProtected(), account, personally, can(rbac.UsersImpersonate), controllers.Impersonate) // act on behalf of the user
 
 	// Routes for /house:
-	route.Post("/house", middleware.JWTProtected(), controllers.CreateHouse)   // create a new house
-	route.Put("/house", middleware.JWTProtected(), controllers.UpdateHouse)    // update a house
-	route.Delete("/house", middleware.JWTProtected(), controllers.DeleteHouse) // delete one house by ID
+	route.Post("/house", middleware.JWTProtected(), can(rbac.HousesWrite), controllers.CreateHouse)                // create a new house
+	route.Put("/house", middleware.JWTProtected(), can(rbac.HousesWrite), controllers.UpdateHouse)                 // update a house
+	route.Delete("/house", middleware.JWTProtected(), personally, can(rbac.HousesDelete), controllers.DeleteHouse) // delete one house by ID
 }
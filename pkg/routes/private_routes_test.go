@@ -1,16 +1,26 @@
 package routes
 
 import (
+	"context"
 	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/app/repository/memory"
+	"github.com/popeskul/houser/app/repository/repositorytest"
+	"github.com/popeskul/houser/pkg/configs"
 	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/audit"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/revocation"
 	"io"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPrivateRoutes(t *testing.T) {
@@ -19,11 +29,23 @@ func TestPrivateRoutes(t *testing.T) {
 		panic(err)
 	}
 
+	memoryRevocation(t)
+
 	// Create a sample data string.
 	dataString := `{"id": "00000000-0000-0000-0000-000000000000"}`
 
+	// Create a sample data string with ID of another user.
+	otherUserString := `{"id": "` + uuid.New().String() + `"}`
+
 	// Create access token.
-	token, err := utils.GenerateNewAccessToken(models.User{Email: "test@mail.com", Password: "test@mail.com"})
+	token := accessToken(t, models.User{Email: "test@mail.com", Password: "test@mail.com"}, uuid.Nil)
+
+	// Create access tokens with roles, which are not allowed to manage users.
+	ownerToken := accessToken(t, models.User{Email: "owner@mail.com", Role: "owner"}, uuid.Nil)
+	viewerToken := accessToken(t, models.User{Email: "viewer@mail.com", Role: "viewer"}, uuid.Nil)
+
+	// Create email verification token, it must not work as access token.
+	verificationToken, _, err := utils.GenerateNewPurposeToken(utils.EmailVerificationPurpose, uuid.New(), nil, time.Hour)
 	if err != nil {
 		panic(err)
 	}
@@ -101,10 +123,91 @@ func TestPrivateRoutes(t *testing.T) {
 			expectedError: false,
 			expectedCode:  fiber.StatusForbidden,
 		},
+		{
+			description:   "create user without admin role",
+			route:         "/api/v1/user",
+			method:        "POST",
+			tokenString:   "Bearer " + ownerToken,
+			body:          strings.NewReader(dataString),
+			expectedError: false,
+			expectedCode:  fiber.StatusForbidden,
+		},
+		{
+			description:   "delete another user without admin role",
+			route:         "/api/v1/user",
+			method:        "DELETE",
+			tokenString:   "Bearer " + ownerToken,
+			body:          strings.NewReader(otherUserString),
+			expectedError: false,
+			expectedCode:  fiber.StatusForbidden,
+		},
+		{
+			description:   "update another user without admin role",
+			route:         "/api/v1/user",
+			method:        "PUT",
+			tokenString:   "Bearer " + viewerToken,
+			body:          strings.NewReader(otherUserString),
+			expectedError: false,
+			expectedCode:  fiber.StatusForbidden,
+		},
+		{
+			description:   "get own profile without JWT",
+			route:         "/api/v1/me",
+			method:        "GET",
+			tokenString:   "",
+			body:          nil,
+			expectedError: false,
+			expectedCode:  fiber.StatusBadRequest,
+		},
+		{
+			description:   "reset two-factor without admin role",
+			route:         "/api/v1/user/mfa",
+			method:        "DELETE",
+			tokenString:   "Bearer " + ownerToken,
+			body:          strings.NewReader(dataString),
+			expectedError: false,
+			expectedCode:  fiber.StatusForbidden,
+		},
+		{
+			description:   "create house with viewer role",
+			route:         "/api/v1/house",
+			method:        "POST",
+			tokenString:   "Bearer " + viewerToken,
+			body:          strings.NewReader(dataString),
+			expectedError: false,
+			expectedCode:  fiber.StatusForbidden,
+		},
+		{
+			description:   "change password without JWT",
+			route:         "/api/v1/me/password",
+			method:        "POST",
+			tokenString:   "",
+			body:          nil,
+			expectedError: false,
+			expectedCode:  fiber.StatusBadRequest,
+		},
+		{
+			description:   "get users without JWT",
+			route:         "/api/v1/users",
+			method:        "GET",
+			tokenString:   "",
+			body:          nil,
+			expectedError: false,
+			expectedCode:  fiber.StatusBadRequest,
+		},
+		{
+			description:   "sign out with email verification token",
+			route:         "/api/v1/sign-out",
+			method:        "POST",
+			tokenString:   "Bearer " + verificationToken,
+			body:          nil,
+			expectedError: false,
+			expectedCode:  fiber.StatusUnauthorized,
+		},
 	}
 
 	// Define a new Fiber app.
-	app := fiber.New()
+	app := fiber.New(configs.FiberConfig())
 
 	// Define routes.
 	PrivateRoutes(app)
@@ -132,3 +235,336 @@ func TestPrivateRoutes(t *testing.T) {
 		assert.Equalf(t, test.expectedCode, resp.StatusCode, test.description)
 	}
 }
+
+func TestSignOutRevokesToken(t *testing.T) {
+	memoryRevocation(t)
+
+	// Create access token.
+	token := accessToken(t, models.User{Email: "test@mail.com"}, uuid.Nil)
+
+	// Define a new Fiber app with routes.
+	app := fiber.New(configs.FiberConfig())
+	PrivateRoutes(app)
+
+	// Sign out with the token, then try to use it again.
+	for _, expectedCode := range []int{fiber.StatusNoContent, fiber.StatusUnauthorized} {
+		req := httptest.NewRequest("POST", "/api/v1/sign-out", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := app.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedCode, resp.StatusCode)
+	}
+}
+
+func TestRevokeAllRejectsTokenOfTheSameSecond(t *testing.T) {
+	store := memoryRevocation(t)
+
+	// Create access token, e.g. stolen right before password reset.
+	user := models.User{ID: uuid.New(), Email: "test@mail.com"}
+	token := accessToken(t, user, uuid.Nil)
+
+	// Revoke all tokens of the user in the same second.
+	assert.NoError(t, store.RevokeAll(context.Background(), user.ID))
+
+	// Define a new Fiber app with routes.
+	app := fiber.New(configs.FiberConfig())
+	PrivateRoutes(app)
+
+	req := httptest.NewRequest("GET", "/api/v1/me/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestTerminatedSessionIsRejected(t *testing.T) {
+	store := memoryRevocation(t)
+
+	// Create access token of the session.
+	user := models.User{ID: uuid.New(), Email: "test@mail.com"}
+	sessionID := uuid.New()
+	token := accessToken(t, user, sessionID)
+
+	// Terminate the session, e.g. from another device.
+	assert.NoError(t, store.Revoke(context.Background(), sessionID, user.ID, time.Now().Add(time.Hour)))
+
+	// Define a new Fiber app with routes.
+	app := fiber.New(configs.FiberConfig())
+	PrivateRoutes(app)
+
+	req := httptest.NewRequest("GET", "/api/v1/me/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req, -1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestImpersonationIsAudited(t *testing.T) {
+	// Keep revoked tokens and audit log in memory, there is no database in tests.
+	memoryRevocation(t)
+	logger := &audit.MemoryLogger{}
+	audit.SetDefault(logger)
+	defer audit.SetDefault(nil)
+
+	// Create token of the owner issued to the admin.
+	user := models.User{ID: uuid.New(), Email: "owner@mail.com", Role: "owner"}
+	actorID := uuid.New()
+	token, tokenID, err := utils.GenerateNewImpersonationToken(user, actorID, time.Minute)
+	if err != nil {
+		panic(err)
+	}
+
+	// Define a new Fiber app with routes.
+	app := fiber.New(configs.FiberConfig())
+	PrivateRoutes(app)
+
+	// Owner may delete houses, but not while impersonated.
+	req := httptest.NewRequest("DELETE", "/api/v1/house", strings.NewReader(`{"id": "00000000-0000-0000-0000-000000000000"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// The denied request is still in the audit log.
+	entries := logger.Entries()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, actorID, entries[0].ActorID)
+		assert.Equal(t, user.ID, entries[0].UserID)
+		assert.Equal(t, tokenID, entries[0].TokenID)
+		assert.Equal(t, models.ImpersonatedRequest, entries[0].Action)
+		assert.Equal(t, "/api/v1/house", entries[0].Path)
+	}
+}
+
+func TestDeleteHouseWithMemoryRepositories(t *testing.T) {
+	// Keep revoked tokens, users and houses in memory, there is no database in tests.
+	memoryRevocation(t)
+	store := memory.NewStore()
+
+	// Create the owner with a house and another owner.
+	owner := repositorytest.NewUser()
+	other := repositorytest.NewUser()
+	house := repositorytest.NewHouse(owner.ID)
+	assert.NoError(t, store.CreateUser(context.Background(), &owner))
+	assert.NoError(t, store.CreateUser(context.Background(), &other))
+	assert.NoError(t, store.CreateHouse(context.Background(), &house))
+
+	ownerToken := accessToken(t, owner, uuid.Nil)
+	otherToken := accessToken(t, other, uuid.Nil)
+
+	// Define a new Fiber app with repositories and routes.
+	app := fiber.New(configs.FiberConfig())
+	app.Use((&container.Container{Users: store, Houses: store, Tx: store}).Inject())
+	PrivateRoutes(app)
+
+	// Only the owner may delete the house, then it is not found anymore.
+	for _, tc := range []struct {
+		token        string
+		expectedCode int
+	}{
+		{otherToken, fiber.StatusForbidden},
+		{ownerToken, fiber.StatusNoContent},
+		{ownerToken, fiber.StatusNotFound},
+	} {
+		req := httptest.NewRequest("DELETE", "/api/v1/house", strings.NewReader(`{"id": "`+house.ID.String()+`"}`))
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expectedCode, resp.StatusCode)
+	}
+
+	houses, err := store.GetHousesByOwner(context.Background(), owner.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, houses)
+}
+
+func TestCookieModeRequiresCSRFToken(t *testing.T) {
+	// Give access token to browsers in cookies.
+	config := *configs.Default()
+	config.Cookie.Enabled = true
+	configs.SetDefault(&config)
+	defer configs.SetDefault(nil)
+
+	memoryRevocation(t)
+
+	// Create access token.
+	token := accessToken(t, models.User{ID: uuid.New(), Email: "test@mail.com"}, uuid.Nil)
+
+	// Define a new Fiber app with routes.
+	app := fiber.New(configs.FiberConfig())
+	PrivateRoutes(app)
+
+	tests := []struct {
+		description  string
+		csrfHeader   string
+		expectedCode int
+	}{
+		{"sign out with cookie without CSRF token", "", fiber.StatusForbidden},
+		{"sign out with cookie with wrong CSRF token", "wrong", fiber.StatusForbidden},
+		{"sign out with cookie with CSRF token", "csrf", fiber.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/api/v1/sign-out", nil)
+		req.Header.Set("Cookie", "houser_access="+token+"; houser_csrf=csrf")
+		if test.csrfHeader != "" {
+			req.Header.Set(utils.CSRFTokenHeader, test.csrfHeader)
+		}
+
+		resp, err := app.Test(req, -1)
+
+		assert.NoErrorf(t, err, test.description)
+		assert.Equalf(t, test.expectedCode, resp.StatusCode, test.description)
+	}
+}
+
+func TestScopedAPIKeyCanNotManageOwnUser(t *testing.T) {
+	// Keep revoked tokens, users and API keys in memory, there is no database in tests.
+	memoryRevocation(t)
+	store := memory.NewStore()
+
+	// Create the user with API key, which may only read houses.
+	user := repositorytest.NewUser()
+	assert.NoError(t, store.CreateUser(context.Background(), &user))
+
+	key, prefix, hash, err := utils.GenerateNewAPIKey()
+	if err != nil {
+		panic(err)
+	}
+	apiKey := models.APIKey{ID: uuid.New(), UserID: user.ID, Name: "read houses", Prefix: prefix, KeyHash: hash, Scopes: []string{"houses:read"}, CreatedAt: time.Now()}
+	assert.NoError(t, store.CreateAPIKey(context.Background(), &apiKey))
+
+	// Define a new Fiber app with repositories and routes.
+	app := fiber.New(configs.FiberConfig())
+	app.Use((&container.Container{Users: store, Houses: store, APIKeys: store, Tx: store}).Inject())
+	PrivateRoutes(app)
+
+	// The key is valid, but it can neither rename nor delete its own user.
+	for _, tc := range []struct {
+		method string
+		body   string
+	}{
+		{"PUT", `{"id": "` + user.ID.String() + `", "name": "Renamed"}`},
+		{"DELETE", `{"id": "` + user.ID.String() + `"}`},
+	} {
+		req := httptest.NewRequest(tc.method, "/api/v1/user", strings.NewReader(tc.body))
+		req.Header.Set("X-API-Key", key)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, tc.method)
+	}
+
+	found, err := store.GetUserById(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Name, found.Name)
+}
+
+func TestDeleteOwnUserRequiresMe(t *testing.T) {
+	// Keep revoked tokens and users in memory, there is no database in tests.
+	memoryRevocation(t)
+	store := memory.NewStore()
+
+	// Even admin deletes own account with the current password only.
+	admin := repositorytest.NewUser()
+	admin.Role = "admin"
+	assert.NoError(t, store.CreateUser(context.Background(), &admin))
+
+	token := accessToken(t, admin, uuid.Nil)
+
+	// Define a new Fiber app with repositories and routes.
+	app := fiber.New(configs.FiberConfig())
+	app.Use((&container.Container{Users: store, Houses: store, Tx: store}).Inject())
+	PrivateRoutes(app)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/user", strings.NewReader(`{"id": "`+admin.ID.String()+`"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	_, err = store.GetUserById(context.Background(), admin.ID)
+	assert.NoError(t, err)
+}
+
+func TestUpdateOwnUserWithViewerRole(t *testing.T) {
+	// Keep revoked tokens and users in memory, there is no database in tests.
+	memoryRevocation(t)
+	store := memory.NewStore()
+
+	// Viewer only reads, but changes own profile.
+	viewer := repositorytest.NewUser()
+	viewer.Role = "viewer"
+	other := repositorytest.NewUser()
+	assert.NoError(t, store.CreateUser(context.Background(), &viewer))
+	assert.NoError(t, store.CreateUser(context.Background(), &other))
+
+	token := accessToken(t, viewer, uuid.Nil)
+
+	// Define a new Fiber app with repositories and routes.
+	app := fiber.New(configs.FiberConfig())
+	app.Use((&container.Container{Users: store, Houses: store, Tx: store}).Inject())
+	PrivateRoutes(app)
+
+	for _, tc := range []struct {
+		method       string
+		body         string
+		expectedCode int
+	}{
+		{"PUT", `{"id": "` + viewer.ID.String() + `", "name": "Renamed"}`, fiber.StatusCreated},
+		{"PUT", `{"id": "` + other.ID.String() + `", "name": "Renamed"}`, fiber.StatusForbidden},
+		{"DELETE", `{"id": "` + other.ID.String() + `"}`, fiber.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tc.method, "/api/v1/user", strings.NewReader(tc.body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expectedCode, resp.StatusCode, tc.method)
+	}
+
+	found, err := store.GetUserById(context.Background(), viewer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", found.Name)
+}
+
+// memoryRevocation func for keep revoked tokens in memory, there is no database in tests.
+// The store is default until the end of the test.
+func memoryRevocation(t *testing.T) *revocation.Store {
+	t.Helper()
+
+	store := revocation.NewStore(revocation.NewMemoryBackend(), time.Minute)
+	revocation.SetDefault(store)
+	t.Cleanup(func() { revocation.SetDefault(nil) })
+
+	return store
+}
+
+// accessToken func for create access token of the user, the test fails on error.
+func accessToken(t *testing.T, user models.User, sessionID uuid.UUID) string {
+	t.Helper()
+
+	token, err := utils.GenerateNewAccessToken(user, sessionID)
+	require.NoError(t, err)
+
+	return token
+}
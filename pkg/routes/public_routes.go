@@ -15,13 +15,29 @@ func PublicRoutes(a *fiber.App) {
 		return c.SendString("App running")
 	})
 
+	// Public signing keys:
+	a.Get("/.well-known/jwks.json", controllers.GetJWKS) // get keys for verifying access tokens
+
 	// Routes auth:
-	route.Post("/sign-in", controllers.SignIn) // login to the system
-	route.Post("/sign-up", controllers.SignUp) // registration
+	route.Post("/sign-in", controllers.SignIn)                   // login to the system
+	route.Post("/sign-in/mfa", controllers.SignInMFA)            // finish login with two-factor code
+	route.Post("/sign-up", controllers.SignUp)                   // registration
+	route.Post("/verify-email", controllers.VerifyEmail)         // confirm email address
+	route.Post("/email/confirm", controllers.ConfirmEmailChange) // confirm change of email address
+
+	// Routes external identity providers:
+	route.Get("/oidc/:provider/authorize", controllers.OIDCAuthorize) // redirect to identity provider
+	route.Get("/oidc/:provider/callback", controllers.OIDCCallback)   // sign in with identity provider
+
+	// Routes password:
+	route.Post("/password/forgot", controllers.ForgotPassword) // send password reset email
+	route.Post("/password/reset", controllers.ResetPassword)   // set a new password with reset token
+
+	// Routes token:
+	route.Post("/token/refresh", controllers.RefreshToken) // renew access and refresh tokens
 
 	// Routes users:
-	route.Get("/users", controllers.GetUsers)   // get list of all users
-	route.Get("/user/:id", controllers.GetUser) // get one user by ID
+	route.Get("/user/:id", controllers.GetUser) // get public profile of one user by ID
 
 	// Routes houses:
 	route.Get("/houses", controllers.GetHouses)   // get list of all users
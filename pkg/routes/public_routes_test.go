@@ -0,0 +1,383 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/oidc"
+	"github.com/popeskul/houser/pkg/oidc/oidctest"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/popeskul/houser/pkg/utils"
+	"github.com/popeskul/houser/platform/audit"
+	"github.com/popeskul/houser/platform/container"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/popeskul/houser/platform/revocation"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCRoutes(t *testing.T) {
+	// Load .env.test file from the root folder.
+	if err := godotenv.Load("../../configs/config.test.yml"); err != nil {
+		panic(err)
+	}
+
+	// Start a local identity provider.
+	server := oidctest.NewServer("houser", "secret")
+	defer server.Close()
+
+	oidc.SetDefault(oidc.NewRegistry(oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       server.URL,
+		ClientID:     "houser",
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "email"},
+		RedirectURL:  "http://localhost/api/v1/oidc/mock/callback",
+	})))
+
+	// Define a new Fiber app.
+	app := fiber.New(configs.FiberConfig())
+
+	// Define routes.
+	PublicRoutes(app)
+
+	// Sign in starts with redirect to the provider and state cookie.
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/oidc/mock/authorize", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+	assert.NotEmpty(t, location.Query().Get("state"))
+	assert.Empty(t, location.Query().Get("code_verifier"))
+	assert.Len(t, resp.Cookies(), 1)
+	stateCookie := resp.Cookies()[0]
+
+	// Callback without the state cookie is rejected.
+	req := httptest.NewRequest("GET", "/api/v1/oidc/mock/callback?code=x&state="+location.Query().Get("state"), nil)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	// Callback with the cookie of another request is rejected.
+	req = httptest.NewRequest("GET", "/api/v1/oidc/mock/callback?code=x&state=forged", nil)
+	req.AddCookie(stateCookie)
+	resp, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	// Unknown providers are not found.
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/v1/oidc/unknown/authorize", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestSignInValidatesInput(t *testing.T) {
+	// Define a new Fiber app with routes, invalid input is rejected before database is used.
+	app := fiber.New(configs.FiberConfig())
+	PublicRoutes(app)
+
+	tests := []struct {
+		description string
+		body        string
+	}{
+		{"invalid email", `{"email": "not an email", "password": "secret"}`},
+		{"too long password", `{"email": "user@mail.com", "password": "` + strings.Repeat("a", 1025) + `"}`},
+	}
+
+	for _, test := range tests {
+		code := postJSON(t, app, "/api/v1/sign-in", test.body, nil)
+		assert.Equal(t, fiber.StatusBadRequest, code, test.description)
+	}
+}
+
+// oidcSignIn func for pass authorization request of the app through the local provider
+// and return response of the callback. The request is started by the given response.
+func oidcSignIn(t *testing.T, app *fiber.App, started *http.Response, authURL string) *http.Response {
+	t.Helper()
+
+	// The provider approves the request and redirects back to the callback.
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", callback.RequestURI(), nil)
+	for _, cookie := range started.Cookies() {
+		req.AddCookie(cookie)
+	}
+
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+
+	return resp
+}
+
+// databaseApp func for create app with all routes on top of PostgreSQL of the test environment.
+// The test is skipped, if PostgreSQL is not running.
+func databaseApp(t *testing.T) (*fiber.App, *database.Queries) {
+	t.Helper()
+
+	// Define database and JWT settings of the test environment, the rest is default.
+	t.Setenv("HOUSER_DB_PASSWORD", "123123")
+	t.Setenv("HOUSER_JWT_SECRET_KEY", "secret")
+	t.Cleanup(viper.Reset)
+	t.Cleanup(func() { configs.SetDefault(nil) })
+	require.NoError(t, configs.EnvConfigs(""))
+
+	// Skip, if PostgreSQL is not running.
+	db, err := database.Open()
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	deps := container.NewWithDB(db)
+	revocation.SetDefault(revocation.NewStore(revocation.NewDatabaseBackend(db), configs.Default().JWT.RevocationCacheTTL))
+	audit.SetDefault(audit.NewDatabaseLogger(db))
+
+	// Define a new Fiber app with dependencies and routes.
+	app := fiber.New(configs.FiberConfig())
+	app.Use(deps.Inject())
+	PublicRoutes(app)
+	PrivateRoutes(app)
+
+	return app, db
+}
+
+func TestOIDCLinking(t *testing.T) {
+	app, db := databaseApp(t)
+
+	// Start a local identity provider.
+	server := oidctest.NewServer("houser", "secret")
+	defer server.Close()
+
+	oidc.SetDefault(oidc.NewRegistry(oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       server.URL,
+		ClientID:     "houser",
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "email"},
+		RedirectURL:  "http://localhost/api/v1/oidc/mock/callback",
+	})))
+
+	// Somebody registered email of the identity, but never verified it.
+	ctx := context.Background()
+	user := models.User{ID: uuid.New(), Name: "Owner", Email: uuid.New().String() + "@mail.com", Password: "-", Role: string(rbac.DefaultRole), CreatedAt: time.Now()}
+	require.NoError(t, db.CreateUser(ctx, &user))
+	defer func() { _ = db.DeleteUser(ctx, user.ID) }()
+
+	server.Identity = oidctest.Identity{Subject: uuid.New().String(), Email: user.Email, EmailVerified: true}
+
+	// Sign in with the identity does not take over the account.
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/oidc/mock/authorize", nil), -1)
+	require.NoError(t, err)
+
+	resp = oidcSignIn(t, app, resp, resp.Header.Get("Location"))
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	// The owner of the account links the identity explicitly.
+	token := accessToken(t, user, uuid.Nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/me/identities/mock/authorize", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var started struct {
+		URL string `json:"url"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+
+	resp = oidcSignIn(t, app, resp, started.URL)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// Now the identity signs in to the account.
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/v1/oidc/mock/authorize", nil), -1)
+	require.NoError(t, err)
+
+	resp = oidcSignIn(t, app, resp, resp.Header.Get("Location"))
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// Linked identity can not be linked to another account.
+	other := models.User{ID: uuid.New(), Name: "Other", Email: uuid.New().String() + "@mail.com", Password: "-", Role: string(rbac.DefaultRole), CreatedAt: time.Now()}
+	require.NoError(t, db.CreateUser(ctx, &other))
+	defer func() { _ = db.DeleteUser(ctx, other.ID) }()
+
+	token = accessToken(t, other, uuid.Nil)
+
+	req = httptest.NewRequest("GET", "/api/v1/me/identities/mock/authorize", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+
+	resp = oidcSignIn(t, app, resp, started.URL)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+// postJSON func for send JSON body to the app and decode JSON response.
+func postJSON(t *testing.T, app *fiber.App, path, body string, response interface{}) int {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+
+	if response != nil {
+		_ = json.NewDecoder(resp.Body).Decode(response)
+	}
+
+	return resp.StatusCode
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	app, db := databaseApp(t)
+
+	// Create the user with verified email.
+	ctx := context.Background()
+	hash, err := utils.HashPassword("first-Passw0rd")
+	require.NoError(t, err)
+
+	user := models.User{ID: uuid.New(), Name: "Owner", Email: uuid.New().String() + "@mail.com", Password: hash, Role: string(rbac.DefaultRole), CreatedAt: time.Now()}
+	require.NoError(t, db.CreateUser(ctx, &user))
+	defer func() { _ = db.DeleteUser(ctx, user.ID) }()
+	_, err = db.VerifyUserEmail(ctx, user.ID, user.Email)
+	require.NoError(t, err)
+
+	type tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Sign in starts a new session.
+	var signedIn tokens
+	code := postJSON(t, app, "/api/v1/sign-in", `{"email": "`+user.Email+`", "password": "first-Passw0rd"}`, &signedIn)
+	require.Equal(t, fiber.StatusOK, code)
+
+	// Refresh token is rotated.
+	var refreshed tokens
+	code = postJSON(t, app, "/api/v1/token/refresh", `{"refresh_token": "`+signedIn.RefreshToken+`"}`, &refreshed)
+	require.Equal(t, fiber.StatusOK, code)
+
+	req := httptest.NewRequest("GET", "/api/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer "+refreshed.AccessToken)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// The rotated token is used again, e.g. it was stolen.
+	code = postJSON(t, app, "/api/v1/token/refresh", `{"refresh_token": "`+signedIn.RefreshToken+`"}`, nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+
+	// The whole session is terminated: the latest refresh token and access tokens are revoked.
+	code = postJSON(t, app, "/api/v1/token/refresh", `{"refresh_token": "`+refreshed.RefreshToken+`"}`, nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+
+	for _, token := range []string{signedIn.AccessToken, refreshed.AccessToken} {
+		req := httptest.NewRequest("GET", "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	}
+
+	sessions, err := db.GetActiveSessions(ctx, user.ID, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestMFAEnrollRequiresCurrentPassword(t *testing.T) {
+	app, db := databaseApp(t)
+
+	// Create the user with verified email.
+	ctx := context.Background()
+	hash, err := utils.HashPassword("first-Passw0rd")
+	require.NoError(t, err)
+
+	user := models.User{ID: uuid.New(), Name: "Owner", Email: uuid.New().String() + "@mail.com", Password: hash, Role: string(rbac.DefaultRole), CreatedAt: time.Now()}
+	require.NoError(t, db.CreateUser(ctx, &user))
+	defer func() { _ = db.DeleteUser(ctx, user.ID) }()
+	_, err = db.VerifyUserEmail(ctx, user.ID, user.Email)
+	require.NoError(t, err)
+
+	var signedIn struct {
+		AccessToken string `json:"access_token"`
+	}
+	code := postJSON(t, app, "/api/v1/sign-in", `{"email": "`+user.Email+`", "password": "first-Passw0rd"}`, &signedIn)
+	require.Equal(t, fiber.StatusOK, code)
+
+	tests := []struct {
+		description  string
+		body         string
+		expectedCode int
+	}{
+		{description: "without current password", body: `{}`, expectedCode: fiber.StatusBadRequest},
+		{description: "with wrong current password", body: `{"current_password": "wrong-Passw0rd"}`, expectedCode: fiber.StatusForbidden},
+		{description: "with current password", body: `{"current_password": "first-Passw0rd"}`, expectedCode: fiber.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/api/v1/mfa/enroll", strings.NewReader(test.body))
+		req.Header.Set("Authorization", "Bearer "+signedIn.AccessToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err, test.description)
+		assert.Equal(t, test.expectedCode, resp.StatusCode, test.description)
+	}
+}
+
+func TestPasswordResetUnlocksAccount(t *testing.T) {
+	app, db := databaseApp(t)
+
+	// Create the user, whose account was locked by failed sign ins.
+	ctx := context.Background()
+	user := models.User{ID: uuid.New(), Name: "Owner", Email: uuid.New().String() + "@mail.com", Password: "-", Role: string(rbac.DefaultRole), CreatedAt: time.Now()}
+	require.NoError(t, db.CreateUser(ctx, &user))
+	defer func() { _ = db.DeleteUser(ctx, user.ID) }()
+
+	key := "account:" + user.Email
+	defer func() { _ = db.ResetSignInThrottle(ctx, key) }()
+	_, err := db.RecordSignInFailure(ctx, key, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, db.BlockSignIn(ctx, key, time.Now().Add(time.Hour), true))
+
+	// Create reset token, e.g. sent by email.
+	token, hash, err := utils.GenerateNewOpaqueToken()
+	require.NoError(t, err)
+	require.NoError(t, db.CreatePasswordResetToken(ctx, &models.PasswordResetToken{
+		ID: uuid.New(), UserID: user.ID, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now(),
+	}))
+
+	// Reset of the password unlocks the account.
+	code := postJSON(t, app, "/api/v1/password/reset", `{"token": "`+token+`", "password": "new-Passw0rd-123"}`, nil)
+	require.Equal(t, fiber.StatusNoContent, code)
+
+	_, err = db.GetSignInThrottle(ctx, key)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	code = postJSON(t, app, "/api/v1/sign-in", `{"email": "`+user.Email+`", "password": "new-Passw0rd-123"}`, nil)
+	assert.NotEqual(t, fiber.StatusTooManyRequests, code)
+}
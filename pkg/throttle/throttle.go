@@ -0,0 +1,68 @@
+// Package throttle describes how failed sign in attempts are slowed down.
+package throttle
+
+import (
+	"time"
+
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// Policy struct to describe backoff and lockout of failed attempts.
+type Policy struct {
+	Window      time.Duration // failures older than the window are forgotten
+	FreeCount   int           // failures allowed without any delay
+	BackoffBase time.Duration // delay after the first not free failure, it doubles every time
+	BackoffMax  time.Duration
+	LockoutAt   int           // failures count to lock out, zero disables lockout
+	Lockout     time.Duration // lockout duration
+}
+
+// Block method for getting how long further attempts are blocked after the given failures count.
+// It reports true, if it is a lockout rather than a backoff.
+func (p Policy) Block(failures int) (time.Duration, bool) {
+	if p.LockoutAt > 0 && failures >= p.LockoutAt {
+		return p.Lockout, true
+	}
+
+	exponent := failures - p.FreeCount - 1
+	if exponent < 0 {
+		return 0, false
+	}
+
+	delay := p.BackoffBase
+	for i := 0; i < exponent && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > p.BackoffMax {
+		delay = p.BackoffMax
+	}
+
+	return delay, false
+}
+
+// AccountPolicy func for getting policy of failures per account from .yml file.
+func AccountPolicy() Policy {
+	config := configs.Default().Throttle
+
+	return Policy{
+		Window:      config.Window,
+		FreeCount:   config.AccountFreeAttempts,
+		BackoffBase: config.BackoffBase,
+		BackoffMax:  config.BackoffMax,
+		LockoutAt:   config.AccountLockoutThreshold,
+		Lockout:     config.AccountLockout,
+	}
+}
+
+// IPPolicy func for getting policy of failures per client IP from .yml file.
+// Clients are never locked out by IP, many users may share one address.
+func IPPolicy() Policy {
+	config := configs.Default().Throttle
+
+	return Policy{
+		Window:      config.Window,
+		FreeCount:   config.IPFreeAttempts,
+		BackoffBase: config.BackoffBase,
+		BackoffMax:  config.BackoffMax,
+	}
+}
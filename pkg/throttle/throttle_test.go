@@ -0,0 +1,42 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyBlock(t *testing.T) {
+	p := Policy{
+		FreeCount:   2,
+		BackoffBase: time.Second,
+		BackoffMax:  5 * time.Second,
+		LockoutAt:   8,
+		Lockout:     time.Hour,
+	}
+
+	for failures, expected := range map[int]time.Duration{
+		0: 0,
+		2: 0,
+		3: time.Second,
+		4: 2 * time.Second,
+		5: 4 * time.Second,
+		6: 5 * time.Second, // capped
+		7: 5 * time.Second,
+	} {
+		delay, locked := p.Block(failures)
+		assert.Equal(t, expected, delay, failures)
+		assert.False(t, locked, failures)
+	}
+
+	delay, locked := p.Block(8)
+	assert.Equal(t, time.Hour, delay)
+	assert.True(t, locked)
+
+	// Without threshold there is no lockout.
+	p.LockoutAt = 0
+	delay, locked = p.Block(100)
+	assert.Equal(t, 5*time.Second, delay)
+	assert.False(t, locked)
+}
@@ -0,0 +1,53 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeRFC6238(t *testing.T) {
+	// SHA1 test seed from RFC 6238 appendix B, codes are truncated to 6 digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := Code(secret, Step(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	// Code of 1111111109 belongs to the step before 1111111111.
+	rfcSecret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+	previous := "081804"
+
+	// Code of the previous step is accepted with skew.
+	step, ok := Validate(rfcSecret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+
+	// And rejected without it.
+	_, ok = Validate(rfcSecret, previous, now, 0)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "12345", now, 1)
+	assert.False(t, ok)
+
+	uri := ProvisioningURI("Houser", "user@mail.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Houser:user@mail.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// APIKeyPrefix marks API keys, so they are easy to recognize in configs and logs.
+const APIKeyPrefix = "hsr_"
+
+// GenerateNewAPIKey func for generate a new API key.
+// Prefix is visible part of the key, only the hash of the whole key is meant to be stored.
+func GenerateNewAPIKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	secret, _, err := GenerateNewOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix = APIKeyPrefix + hex.EncodeToString(b)
+	key = prefix + "_" + secret
+
+	return key, prefix, HashToken(key), nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// ErrInvalidCSRFToken is returned, if cookie authenticated request has no matching CSRF token.
+var ErrInvalidCSRFToken = errors.New("forbidden, CSRF token is missing or invalid")
+
+// CSRFTokenHeader is the request header with copy of CSRF cookie.
+const CSRFTokenHeader = "X-CSRF-Token"
+
+// RefreshCookiePath is the only path, where browsers send refresh token cookie.
+const RefreshCookiePath = "/api/v1/token/refresh"
+
+// CookieModeEnabled func reports whether access tokens are given to browsers in cookies.
+func CookieModeEnabled() bool {
+	return configs.Default().Cookie.Enabled
+}
+
+// SetAuthCookies func for give access token in HttpOnly cookie with a new CSRF token.
+// CSRF cookie is readable by scripts, they send it back in "X-CSRF-Token" header.
+func SetAuthCookies(c *fiber.Ctx, accessToken string, expires time.Time) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	csrfToken := base64.RawURLEncoding.EncodeToString(b)
+
+	c.Cookie(authCookie(configs.Default().Cookie.AccessCookieName, accessToken, expires, true))
+	c.Cookie(authCookie(configs.Default().Cookie.CSRFCookieName, csrfToken, expires, false))
+
+	return csrfToken, nil
+}
+
+// SetRefreshCookie func for give refresh token in HttpOnly cookie.
+// It is sent only to the refresh endpoint, so other requests never carry it.
+func SetRefreshCookie(c *fiber.Ctx, refreshToken string, expires time.Time) {
+	cookie := authCookie(configs.Default().Cookie.RefreshCookieName, refreshToken, expires, true)
+	cookie.Path = RefreshCookiePath
+
+	c.Cookie(cookie)
+}
+
+// RefreshCookie func for getting refresh token from cookie of the request.
+func RefreshCookie(c *fiber.Ctx) string {
+	return c.Cookies(configs.Default().Cookie.RefreshCookieName)
+}
+
+// ClearAuthCookies func for remove access token, refresh token and CSRF cookies from the browser.
+func ClearAuthCookies(c *fiber.Ctx) {
+	expired := time.Unix(0, 0)
+
+	c.Cookie(authCookie(configs.Default().Cookie.AccessCookieName, "", expired, true))
+	c.Cookie(authCookie(configs.Default().Cookie.CSRFCookieName, "", expired, false))
+	SetRefreshCookie(c, "", expired)
+}
+
+// TokenFromCookie func reports whether access token of the request is taken from cookie.
+func TokenFromCookie(c *fiber.Ctx) bool {
+	return CookieModeEnabled() && c.Get(fiber.HeaderAuthorization) == "" && accessCookie(c) != ""
+}
+
+// ValidCSRFToken func reports whether "X-CSRF-Token" header matches CSRF cookie.
+func ValidCSRFToken(c *fiber.Ctx) bool {
+	cookie := c.Cookies(configs.Default().Cookie.CSRFCookieName)
+	header := c.Get(CSRFTokenHeader)
+
+	return cookie != "" && subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+// SafeMethod func reports whether HTTP method does not change state.
+func SafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func accessCookie(c *fiber.Ctx) string {
+	return c.Cookies(configs.Default().Cookie.AccessCookieName)
+}
+
+func authCookie(name, value string, expires time.Time, httpOnly bool) *fiber.Cookie {
+	config := configs.Default().Cookie
+
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   config.Domain,
+		Expires:  expires,
+		Secure:   config.Secure,
+		HTTPOnly: httpOnly,
+		SameSite: config.SameSite,
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshCookie(t *testing.T) {
+	// Give tokens to browsers in cookies.
+	config := *configs.Default()
+	config.Cookie.Enabled = true
+	configs.SetDefault(&config)
+	defer configs.SetDefault(nil)
+
+	app := fiber.New(configs.FiberConfig())
+	app.Post("/sign-in", func(c *fiber.Ctx) error {
+		SetRefreshCookie(c, "refresh", time.Now().Add(time.Hour))
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Post(RefreshCookiePath, func(c *fiber.Ctx) error {
+		return c.SendString(RefreshCookie(c))
+	})
+	app.Post("/sign-out", func(c *fiber.Ctx) error {
+		ClearAuthCookies(c)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// Refresh token is HttpOnly and scoped to the refresh endpoint.
+	resp, err := app.Test(httptest.NewRequest("POST", "/sign-in", nil), -1)
+	require.NoError(t, err)
+	require.Len(t, resp.Cookies(), 1)
+
+	cookie := resp.Cookies()[0]
+	assert.Equal(t, "houser_refresh", cookie.Name)
+	assert.Equal(t, "refresh", cookie.Value)
+	assert.Equal(t, RefreshCookiePath, cookie.Path)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+
+	// The refresh endpoint reads it.
+	req := httptest.NewRequest("POST", RefreshCookiePath, nil)
+	req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+
+	body := make([]byte, 16)
+	n, _ := resp.Body.Read(body)
+	assert.Equal(t, "refresh", string(body[:n]))
+
+	// Sign out clears all cookies, the refresh one at its own path.
+	resp, err = app.Test(httptest.NewRequest("POST", "/sign-out", nil), -1)
+	require.NoError(t, err)
+
+	cleared := map[string]*http.Cookie{}
+	for _, c := range resp.Cookies() {
+		cleared[c.Name] = c
+	}
+	assert.Len(t, cleared, 3)
+	if assert.Contains(t, cleared, "houser_refresh") {
+		assert.Empty(t, cleared["houser_refresh"].Value)
+		assert.Equal(t, RefreshCookiePath, cleared["houser_refresh"].Path)
+		assert.True(t, cleared["houser_refresh"].Expires.Before(time.Now()))
+	}
+}
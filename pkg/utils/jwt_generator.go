@@ -1,34 +1,155 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
 	"github.com/popeskul/houser/app/models"
-	"github.com/spf13/viper"
-	"strconv"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/keystore"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
-// GenerateNewAccessToken func for generate a new Access token.
-func GenerateNewAccessToken(user models.User) (string, error) {
-	// Set secret key from .yml file.
-	secret := viper.GetString("jwt_secret_key")
+// Tokens struct to describe tokens object.
+type Tokens struct {
+	Access  string
+	Refresh string
+}
+
+// GenerateNewAccessToken func for generate a new Access token of the session.
+func GenerateNewAccessToken(user models.User, sessionID uuid.UUID) (string, error) {
+	// Create a new claims.
+	claims := jwt.MapClaims{}
+
+	// Set public claims:
+	claims["jti"] = uuid.New()
+	claims["iat"] = issuedAt(time.Now())
+	claims["exp"] = AccessTokenExpiresAt().Unix()
+	claims["user_id"] = user.ID
+	claims["role"] = user.Role
+	if sessionID != uuid.Nil {
+		claims["sid"] = sessionID
+	}
 
-	// Set expires minutes count for secret key from .yml file.
-	minutesCount, _ := strconv.Atoi(viper.GetString("jwt_secret_key_expire_minutes_count"))
+	// Generate token.
+	return signToken(claims)
+}
 
+// GenerateNewImpersonationToken func for generate a new Access token of the user for the actor.
+// Actor ID is kept in "act" claim, see: https://datatracker.ietf.org/doc/html/rfc8693#section-4.1
+func GenerateNewImpersonationToken(user models.User, actorID uuid.UUID, ttl time.Duration) (string, uuid.UUID, error) {
 	// Create a new claims.
 	claims := jwt.MapClaims{}
 
 	// Set public claims:
-	claims["exp"] = time.Now().Add(time.Minute * time.Duration(minutesCount)).Unix()
+	jti := uuid.New()
+	claims["jti"] = jti
+	claims["iat"] = issuedAt(time.Now())
+	claims["exp"] = time.Now().Add(ttl).Unix()
 	claims["user_id"] = user.ID
+	claims["role"] = user.Role
+	claims["act"] = map[string]interface{}{"sub": actorID}
+
+	// Generate token.
+	t, err := signToken(claims)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	return t, jti, nil
+}
+
+// GenerateNewRefreshToken func for generate a new opaque Refresh token.
+// Only the hash of the token is meant to be stored.
+func GenerateNewRefreshToken() (token string, hash string, err error) {
+	return GenerateNewOpaqueToken()
+}
+
+// GenerateNewOpaqueToken func for generate a new random token and its hash.
+func GenerateNewOpaqueToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashToken(token), nil
+}
+
+// AccessTokenExpiresAt func for getting expiration time of a new Access token.
+func AccessTokenExpiresAt() time.Time {
+	return time.Now().Add(configs.Default().JWT.AccessTokenTTL)
+}
+
+// RefreshTokenExpiresAt func for getting expiration time of a new Refresh token.
+func RefreshTokenExpiresAt() time.Time {
+	return time.Now().Add(configs.Default().JWT.RefreshTokenTTL)
+}
+
+// HashToken func for hash opaque token before saving it to the database.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Purposes of signed special tokens, they are never accepted as access tokens.
+const (
+	EmailVerificationPurpose = "email_verification"
+	EmailChangePurpose       = "email_change"
+	MFAPendingPurpose        = "mfa_pending"
+	OIDCStatePurpose         = "oidc_state"
+)
+
+// GenerateNewPurposeToken func for generate a new signed token for one purpose.
+// It returns token ID, so the caller can make the token single-use.
+func GenerateNewPurposeToken(purpose string, userID uuid.UUID, extra map[string]interface{}, ttl time.Duration) (string, uuid.UUID, error) {
+	// Create a new claims.
+	claims := jwt.MapClaims{}
+	for name, value := range extra {
+		claims[name] = value
+	}
+
+	// Set public claims:
+	jti := uuid.New()
+	claims["jti"] = jti
+	claims["iat"] = issuedAt(time.Now())
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	claims["user_id"] = userID
+	claims["purpose"] = purpose
+
+	// Generate token.
+	t, err := signToken(claims)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	return t, jti, nil
+}
+
+// issuedAt func for format issue time of the token in seconds with milliseconds.
+// Revocation of all user tokens compares issue time, whole seconds would leave
+// tokens issued in the same second as the revocation valid.
+func issuedAt(now time.Time) float64 {
+	return float64(now.UnixMilli()) / 1000
+}
+
+// signToken func for sign claims with the current key from keystore.
+func signToken(claims jwt.MapClaims) (string, error) {
+	// Get current signing key.
+	key := keystore.Default().SigningKey()
 
-	// Create a new JWT access token with claims.
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Create a new JWT token with claims.
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
 
 	// Generate token.
-	t, err := token.SignedString([]byte(secret))
+	t, err := token.SignedString(key.Private)
 	if err != nil {
 		// Return error, it JWT token generation failed.
 		return "", err
@@ -1,21 +1,96 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
-	"github.com/spf13/viper"
+	"github.com/popeskul/houser/pkg/keystore"
+	"github.com/popeskul/houser/pkg/rbac"
+	"math"
 	"strings"
+	"time"
 )
 
+// ErrMissingToken is returned, if request has no token.
+var ErrMissingToken = errors.New("Missing or malformed JWT")
+
+// ErrTokenWithoutID is returned for tokens issued before token IDs were introduced.
+var ErrTokenWithoutID = errors.New("token has no ID, sign in again")
+
+// ErrInvalidActor is returned for impersonation tokens without valid actor.
+var ErrInvalidActor = errors.New("token has invalid actor")
+
+// ErrWrongTokenPurpose is returned, if token was issued for another purpose.
+var ErrWrongTokenPurpose = errors.New("token was issued for another purpose")
+
 // TokenMetadata struct to describe metadata in JWT.
+// It is the identity of the request, API keys fill it too.
 type TokenMetadata struct {
-	Expires int64
-	UserId  uuid.UUID
+	ID        uuid.UUID
+	IssuedAt  time.Time
+	Expires   int64
+	UserId    uuid.UUID
+	Role      rbac.Role
+	SessionID uuid.UUID         // session of access token, tokens issued before sessions have none
+	APIKeyID  uuid.UUID         // set, if request is authenticated with API key
+	ActorID   uuid.UUID         // admin, who impersonates the user
+	Scopes    []rbac.Permission // scopes of API key, access tokens are not limited by scopes
+}
+
+// Can method reports whether the request may use the permission.
+// API keys need both the role permission and the scope.
+func (m *TokenMetadata) Can(permission rbac.Permission) bool {
+	return rbac.Can(m.Role, permission) && m.InScope(permission)
+}
+
+// InScope method reports whether credentials of the request are not limited
+// by scopes, or have the permission in them. Only API keys have scopes.
+func (m *TokenMetadata) InScope(permission rbac.Permission) bool {
+	if m.APIKeyID == uuid.Nil {
+		return true
+	}
+
+	for _, scope := range m.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Impersonating method reports whether the request is made by an admin on behalf of the user.
+func (m *TokenMetadata) Impersonating() bool {
+	return m.ActorID != uuid.Nil
+}
+
+// CanManage method reports whether the request may change a resource of the owner.
+// The owner needs own permission in scope of API key, anyone else needs others permission.
+func (m *TokenMetadata) CanManage(ownerID uuid.UUID, own, others rbac.Permission) bool {
+	if m.UserId == ownerID {
+		return m.InScope(own)
+	}
+
+	return m.Can(others)
+}
+
+// tokenMetadataKey is used to keep verified metadata in request locals.
+const tokenMetadataKey = "jwt"
+
+// SetTokenMetadata func to keep identity authenticated by other credentials in request locals.
+func SetTokenMetadata(c *fiber.Ctx, tokenMetadata *TokenMetadata) {
+	c.Locals(tokenMetadataKey, tokenMetadata)
 }
 
 // ExtractTokenMetadata func to extract metadata from JWT.
+// Metadata verified earlier in the request is reused.
 func ExtractTokenMetadata(c *fiber.Ctx) (*TokenMetadata, error) {
+	if tokenMetadata, ok := c.Locals(tokenMetadataKey).(*TokenMetadata); ok {
+		return tokenMetadata, nil
+	}
+
 	token, err := verifyToken(c)
 	if err != nil {
 		return nil, err
@@ -24,21 +99,99 @@ func ExtractTokenMetadata(c *fiber.Ctx) (*TokenMetadata, error) {
 	// Setting and checking token and credentials.
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if ok && token.Valid {
-		// Expires time.
+		// Special purpose tokens are never accepted as access tokens.
+		if claimString(claims, "purpose") != "" {
+			return nil, ErrWrongTokenPurpose
+		}
+
+		// Token ID, every access token has it to be revocable.
+		id, err := uuid.Parse(claimString(claims, "jti"))
+		if err != nil {
+			return nil, ErrTokenWithoutID
+		}
+
+		// Issued and expires time.
+		iat, _ := claims["iat"].(float64)
+		issuedAt := time.UnixMilli(int64(math.Round(iat * 1000)))
 		expires := int64(claims["exp"].(float64))
 		userId := uuid.MustParse(claims["user_id"].(string))
 
-		return &TokenMetadata{
-			Expires: expires,
-			UserId:  userId,
-		}, nil
+		// Session ID is optional.
+		sessionID, _ := uuid.Parse(claimString(claims, "sid"))
+
+		// Actor is set for impersonation tokens only.
+		var actorID uuid.UUID
+		if act, ok := claims["act"].(map[string]interface{}); ok {
+			if actorID, err = uuid.Parse(fmt.Sprint(act["sub"])); err != nil {
+				return nil, ErrInvalidActor
+			}
+		}
+
+		tokenMetadata := &TokenMetadata{
+			ID:        id,
+			SessionID: sessionID,
+			ActorID:   actorID,
+			IssuedAt:  issuedAt,
+			Expires:   expires,
+			UserId:    userId,
+			Role:      rbac.Role(claimString(claims, "role")),
+		}
+
+		c.Locals(tokenMetadataKey, tokenMetadata)
+
+		return tokenMetadata, nil
 	}
 
 	return nil, err
 }
 
+// PurposeTokenMetadata struct to describe metadata in special purpose JWT.
+type PurposeTokenMetadata struct {
+	ID     uuid.UUID
+	UserId uuid.UUID
+	Claims map[string]interface{}
+}
+
+// ParsePurposeToken func to verify special purpose JWT and extract its metadata.
+func ParsePurposeToken(tokenString, purpose string) (*PurposeTokenMetadata, error) {
+	token, err := jwt.Parse(tokenString, jwtKeyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("token is invalid")
+	}
+
+	if claimString(claims, "purpose") != purpose {
+		return nil, ErrWrongTokenPurpose
+	}
+
+	id, err := uuid.Parse(claimString(claims, "jti"))
+	if err != nil {
+		return nil, ErrTokenWithoutID
+	}
+
+	userId, err := uuid.Parse(claimString(claims, "user_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PurposeTokenMetadata{ID: id, UserId: userId, Claims: claims}, nil
+}
+
+func claimString(claims jwt.MapClaims, key string) string {
+	value, _ := claims[key].(string)
+
+	return value
+}
+
 func verifyToken(c *fiber.Ctx) (*jwt.Token, error) {
 	tokenString := extractToken(c)
+	if tokenString == "" {
+		return nil, ErrMissingToken
+	}
 
 	token, err := jwt.Parse(tokenString, jwtKeyFunc)
 	if err != nil {
@@ -57,9 +210,26 @@ func extractToken(c *fiber.Ctx) string {
 		return onlyToken[1]
 	}
 
+	// Browsers in cookie mode keep access token in HttpOnly cookie.
+	if TokenFromCookie(c) {
+		return accessCookie(c)
+	}
+
 	return ""
 }
 
 func jwtKeyFunc(token *jwt.Token) (interface{}, error) {
-	return []byte(viper.GetString("jwt_secret_key")), nil
+	kid, _ := token.Header["kid"].(string)
+
+	key, err := keystore.Default().VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checking signing method, so a public key can never be used as HMAC secret.
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+
+	return key.Public, nil
 }
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/pkg/rbac"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanManage(t *testing.T) {
+	ownerID := uuid.New()
+
+	// Access tokens of the owner manage own resources without other permissions.
+	token := &TokenMetadata{UserId: ownerID, Role: rbac.RoleOwner}
+	assert.True(t, token.CanManage(ownerID, rbac.HousesWrite, rbac.HousesManage))
+	assert.True(t, token.CanManage(ownerID, rbac.UsersWrite, rbac.UsersWrite))
+	assert.False(t, token.CanManage(uuid.New(), rbac.HousesWrite, rbac.HousesManage))
+
+	// API keys of the owner need the scope even for own resources.
+	key := &TokenMetadata{UserId: ownerID, Role: rbac.RoleOwner, APIKeyID: uuid.New(), Scopes: []rbac.Permission{rbac.HousesRead, rbac.HousesWrite}}
+	assert.True(t, key.CanManage(ownerID, rbac.HousesWrite, rbac.HousesManage))
+	assert.False(t, key.CanManage(ownerID, rbac.HousesDelete, rbac.HousesManage))
+	assert.False(t, key.CanManage(ownerID, rbac.UsersWrite, rbac.UsersWrite))
+
+	// Others need both role permission and scope.
+	admin := &TokenMetadata{UserId: uuid.New(), Role: rbac.RoleAdmin, APIKeyID: uuid.New(), Scopes: []rbac.Permission{rbac.HousesManage}}
+	assert.True(t, admin.CanManage(ownerID, rbac.HousesWrite, rbac.HousesManage))
+	assert.False(t, admin.CanManage(ownerID, rbac.UsersDelete, rbac.UsersDelete))
+}
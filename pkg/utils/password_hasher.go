@@ -0,0 +1,308 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Names of the supported password hashing algorithms.
+const (
+	Argon2idAlgorithm = "argon2id"
+	BcryptAlgorithm   = "bcrypt"
+)
+
+// LegacyPlaintextPrefix marks stored passwords, which were saved before hashing.
+// Migration 14 adds it to every value, which is not a hash.
+const LegacyPlaintextPrefix = "$plain$"
+
+// ErrInvalidPasswordHash is returned when a stored hash can not be decoded.
+var ErrInvalidPasswordHash = errors.New("password hash is not in the correct format")
+
+// ErrPasswordTooLong is returned by bcrypt hasher, it would ignore bytes after the 72nd one.
+var ErrPasswordTooLong = errors.New("password is too long for bcrypt")
+
+// PasswordHasher interface to describe a password hashing algorithm.
+type PasswordHasher interface {
+	// Algorithm returns the algorithm name.
+	Algorithm() string
+	// Hash returns the encoded hash of the given password.
+	Hash(password string) (string, error)
+	// Verify checks the password against the encoded hash in constant time.
+	Verify(encoded, password string) (bool, error)
+	// Supports reports whether the encoded hash was produced by this algorithm.
+	Supports(encoded string) bool
+	// NeedsRehash reports whether the encoded hash uses other parameters.
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idHasher struct to describe argon2id parameters.
+type Argon2idHasher struct {
+	Memory      uint32 // memory in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Algorithm func returns the algorithm name.
+func (h *Argon2idHasher) Algorithm() string {
+	return Argon2idAlgorithm
+}
+
+// Hash func for hash password with argon2id in PHC string format.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify func for compare password with argon2id hash.
+func (h *Argon2idHasher) Verify(encoded, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+// Supports func checks the argon2id hash prefix.
+func (h *Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// NeedsRehash func compares hash parameters with the current ones.
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, _, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != h.Memory ||
+		params.Iterations != h.Iterations ||
+		params.Parallelism != h.Parallelism ||
+		params.KeyLength != h.KeyLength ||
+		uint32(len(salt)) != h.SaltLength
+}
+
+func decodeArgon2idHash(encoded string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != Argon2idAlgorithm {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// BcryptHasher struct to describe bcrypt parameters.
+type BcryptHasher struct {
+	Cost int
+}
+
+// Algorithm func returns the algorithm name.
+func (h *BcryptHasher) Algorithm() string {
+	return BcryptAlgorithm
+}
+
+// Hash func for hash password with bcrypt.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > passwordpolicy.BcryptMaxBytes {
+		return "", ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify func for compare password with bcrypt hash.
+func (h *BcryptHasher) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Supports func checks the bcrypt hash prefix.
+func (h *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash func compares hash cost with the current one.
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+
+	return cost != h.Cost
+}
+
+// NewPasswordHasher func for create a password hasher from .yml file.
+func NewPasswordHasher() PasswordHasher {
+	if configs.Default().Hasher.Algorithm == BcryptAlgorithm {
+		return newBcryptHasher()
+	}
+
+	return newArgon2idHasher()
+}
+
+func newArgon2idHasher() *Argon2idHasher {
+	// Defaults follow the OWASP recommendation for argon2id.
+	h := &Argon2idHasher{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+
+	config := configs.Default().Hasher
+	if config.Argon2idMemory > 0 {
+		h.Memory = config.Argon2idMemory
+	}
+	if config.Argon2idIterations > 0 {
+		h.Iterations = config.Argon2idIterations
+	}
+	if config.Argon2idParallelism > 0 {
+		h.Parallelism = config.Argon2idParallelism
+	}
+
+	return h
+}
+
+func newBcryptHasher() *BcryptHasher {
+	h := &BcryptHasher{Cost: bcrypt.DefaultCost}
+
+	if cost := configs.Default().Hasher.BcryptCost; cost > 0 {
+		h.Cost = cost
+	}
+
+	return h
+}
+
+// HashPassword func for hash password with the configured hasher.
+func HashPassword(password string) (string, error) {
+	return NewPasswordHasher().Hash(password)
+}
+
+var (
+	dummyMu   sync.Mutex
+	dummyHash string // hash of nobody by the configured hasher
+)
+
+// VerifyDummyPassword func for verify password against hash of nobody, e.g. for
+// unknown emails on sign in. It takes as long as VerifyPassword for registered
+// users, so response time does not reveal, which emails are registered.
+func VerifyDummyPassword(password string) error {
+	hasher := NewPasswordHasher()
+
+	// Hash is made once and again only after the hasher settings change.
+	dummyMu.Lock()
+	if !hasher.Supports(dummyHash) || hasher.NeedsRehash(dummyHash) {
+		hash, err := hasher.Hash("password of nobody")
+		if err != nil {
+			dummyMu.Unlock()
+			return err
+		}
+		dummyHash = hash
+	}
+	encoded := dummyHash
+	dummyMu.Unlock()
+
+	_, err := hasher.Verify(encoded, password)
+
+	return err
+}
+
+// VerifyPassword func for compare password with the stored hash.
+// It returns whether the password matches and whether the stored value
+// should be replaced by a fresh hash from the configured hasher. Legacy
+// plaintext values never match, unless password.legacy_plaintext is enabled.
+func VerifyPassword(encoded, password string) (match bool, rehash bool, err error) {
+	// Legacy plaintext password, upgrade it on successful sign in.
+	if strings.HasPrefix(encoded, LegacyPlaintextPrefix) {
+		if !configs.Default().Hasher.LegacyPlaintext {
+			return false, false, nil
+		}
+
+		plaintext := strings.TrimPrefix(encoded, LegacyPlaintextPrefix)
+		match = subtle.ConstantTimeCompare([]byte(plaintext), []byte(password)) == 1
+
+		return match, match, nil
+	}
+
+	current := NewPasswordHasher()
+
+	for _, hasher := range []PasswordHasher{current, newArgon2idHasher(), newBcryptHasher()} {
+		if !hasher.Supports(encoded) {
+			continue
+		}
+
+		match, err = hasher.Verify(encoded, password)
+		if err != nil || !match {
+			return false, false, err
+		}
+
+		rehash = hasher.Algorithm() != current.Algorithm() || current.NeedsRehash(encoded)
+
+		return true, rehash, nil
+	}
+
+	return false, false, ErrInvalidPasswordHash
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	// Use cheap parameters, the algorithms are what is under test here.
+	config := *configs.Default()
+	config.Hasher.Argon2idMemory = 1024
+	config.Hasher.Argon2idIterations = 1
+	config.Hasher.BcryptCost = 4
+	configs.SetDefault(&config)
+	defer configs.SetDefault(nil)
+
+	argonHash, err := HashPassword("secret-password")
+	assert.NoError(t, err)
+
+	bcryptHash, err := (&BcryptHasher{Cost: 4}).Hash("secret-password")
+	assert.NoError(t, err)
+
+	weakArgonHash, err := (&Argon2idHasher{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}).Hash("secret-password")
+	assert.NoError(t, err)
+
+	// Define a structure for specifying input and output data of a single test case.
+	tests := []struct {
+		description    string
+		encoded        string
+		password       string
+		expectedMatch  bool
+		expectedRehash bool
+	}{
+		{
+			description:    "argon2id hash with right password",
+			encoded:        argonHash,
+			password:       "secret-password",
+			expectedMatch:  true,
+			expectedRehash: false,
+		},
+		{
+			description:    "argon2id hash with wrong password",
+			encoded:        argonHash,
+			password:       "wrong-password",
+			expectedMatch:  false,
+			expectedRehash: false,
+		},
+		{
+			description:    "argon2id hash with outdated parameters",
+			encoded:        weakArgonHash,
+			password:       "secret-password",
+			expectedMatch:  true,
+			expectedRehash: true,
+		},
+		{
+			description:    "bcrypt hash while argon2id is configured",
+			encoded:        bcryptHash,
+			password:       "secret-password",
+			expectedMatch:  true,
+			expectedRehash: true,
+		},
+		{
+			description:    "legacy plaintext is disabled",
+			encoded:        LegacyPlaintextPrefix + "secret-password",
+			password:       "secret-password",
+			expectedMatch:  false,
+			expectedRehash: false,
+		},
+	}
+
+	// Iterate through test single test cases
+	for _, test := range tests {
+		match, rehash, err := VerifyPassword(test.encoded, test.password)
+
+		assert.NoErrorf(t, err, test.description)
+		assert.Equalf(t, test.expectedMatch, match, test.description)
+		assert.Equalf(t, test.expectedRehash, rehash, test.description)
+	}
+
+	// Values without known prefix are not taken for plaintext.
+	_, _, err = VerifyPassword("secret-password", "secret-password")
+	assert.ErrorIs(t, err, ErrInvalidPasswordHash)
+}
+
+func TestVerifyLegacyPlaintextPassword(t *testing.T) {
+	// Accept legacy plaintext passwords during migration.
+	config := *configs.Default()
+	config.Hasher.LegacyPlaintext = true
+	configs.SetDefault(&config)
+	defer configs.SetDefault(nil)
+
+	// Define a structure for specifying input and output data of a single test case.
+	tests := []struct {
+		description    string
+		encoded        string
+		password       string
+		expectedMatch  bool
+		expectedRehash bool
+	}{
+		{
+			description:    "legacy plaintext with right password",
+			encoded:        LegacyPlaintextPrefix + "secret-password",
+			password:       "secret-password",
+			expectedMatch:  true,
+			expectedRehash: true,
+		},
+		{
+			description:    "legacy plaintext with wrong password",
+			encoded:        LegacyPlaintextPrefix + "secret-password",
+			password:       "wrong-password",
+			expectedMatch:  false,
+			expectedRehash: false,
+		},
+		{
+			description:    "legacy plaintext, which looks like a hash",
+			encoded:        LegacyPlaintextPrefix + "$2a$secret",
+			password:       "$2a$secret",
+			expectedMatch:  true,
+			expectedRehash: true,
+		},
+	}
+
+	// Iterate through test single test cases
+	for _, test := range tests {
+		match, rehash, err := VerifyPassword(test.encoded, test.password)
+
+		assert.NoErrorf(t, err, test.description)
+		assert.Equalf(t, test.expectedMatch, match, test.description)
+		assert.Equalf(t, test.expectedRehash, rehash, test.description)
+	}
+}
+
+func TestBcryptHasherRejectsLongPassword(t *testing.T) {
+	hasher := &BcryptHasher{Cost: 4}
+
+	_, err := hasher.Hash(strings.Repeat("a", 73))
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+
+	_, err = hasher.Hash(strings.Repeat("a", 72))
+	assert.NoError(t, err)
+}
+
+func TestVerifyDummyPassword(t *testing.T) {
+	// Use cheap parameters, the algorithms are what is under test here.
+	config := *configs.Default()
+	config.Hasher.Argon2idMemory = 1024
+	config.Hasher.Argon2idIterations = 1
+	config.Hasher.BcryptCost = 4
+	configs.SetDefault(&config)
+	defer configs.SetDefault(nil)
+
+	// Hash of nobody is made by the configured hasher, so it costs the same.
+	assert.NoError(t, VerifyDummyPassword("secret-password"))
+	assert.True(t, NewPasswordHasher().Supports(dummyHash))
+	assert.False(t, NewPasswordHasher().NeedsRehash(dummyHash))
+
+	// It follows changes of the hasher.
+	config.Hasher.Algorithm = BcryptAlgorithm
+	assert.NoError(t, VerifyDummyPassword("secret-password"))
+	assert.True(t, (&BcryptHasher{}).Supports(dummyHash))
+	assert.False(t, NewPasswordHasher().NeedsRehash(dummyHash))
+}
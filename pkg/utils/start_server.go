@@ -1,21 +1,36 @@
 package utils
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/configs"
 )
 
 // StartServerWithGracefulShutdown function for starting server with a graceful shutdown.
-func StartServerWithGracefulShutdown(a *fiber.App) {
+// The given closers are closed after the server is stopped, e.g. database connection pool.
+// Error is returned, if the server can not be started, e.g. the port is busy.
+func StartServerWithGracefulShutdown(a *fiber.App, closers ...io.Closer) error {
 	// idleConsClosed for idle connections.
 	idleConsClosed := make(chan struct{})
 
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt) // Catch OS signals.
+	defer signal.Stop(sigint)
+
+	// listenFailed stops waiting for signal, if the server was not started.
+	listenFailed := make(chan struct{})
+
 	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt) // Catch OS signals.
-		<-sigint
+		select {
+		case <-sigint:
+		case <-listenFailed:
+			return
+		}
 
 		// Received an interrupt signal, shutdown.
 		if err := a.Shutdown(); err != nil {
@@ -26,10 +41,21 @@ func StartServerWithGracefulShutdown(a *fiber.App) {
 		close(idleConsClosed)
 	}()
 
-	// Run server.
-	if err := a.Listen(":8080"); err != nil {
+	// Run server, it returns without error only after shutdown.
+	err := a.Listen(":" + strconv.Itoa(configs.Default().Server.Port))
+	if err != nil {
 		log.Printf("Oops... Server is not running! Reason: %v", err)
+		close(listenFailed)
+	} else {
+		<-idleConsClosed
+	}
+
+	// Release dependencies, when no request uses them anymore.
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			log.Printf("Oops... Dependency is not closed! Reason: %v", err)
+		}
 	}
 
-	<-idleConsClosed
+	return err
 }
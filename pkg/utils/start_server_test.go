@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closerFunc type to describe dependency, which is closed by the given func.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestStartServerWithBusyPort(t *testing.T) {
+	// Another process listens on the port already.
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	config := *configs.Default()
+	config.Server.Port = listener.Addr().(*net.TCPAddr).Port
+	configs.SetDefault(&config)
+	defer configs.SetDefault(nil)
+
+	// Server returns the error instead of waiting for shutdown, dependencies are released.
+	closed := false
+	err = StartServerWithGracefulShutdown(fiber.New(configs.FiberConfig()), closerFunc(func() error {
+		closed = true
+		return nil
+	}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), strconv.Itoa(config.Server.Port))
+	assert.True(t, closed)
+}
@@ -0,0 +1,67 @@
+package utils
+
+import "strings"
+
+// DeviceName func for getting human readable device name from User-Agent header,
+// e.g. "Chrome on Windows". Other clients are named by their product token.
+func DeviceName(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"CriOS/", "Chrome"},
+		{"Safari/", "Safari"},
+	})
+	system := firstMatch(userAgent, [][2]string{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	// Non browser clients, e.g. "curl/7.79.1" or "okhttp/4.9.0".
+	product := strings.Fields(userAgent)[0]
+	if name, _, found := cut(product, "/"); found {
+		product = name
+	}
+	if len(product) > 64 {
+		product = product[:64]
+	}
+
+	return product
+}
+
+func firstMatch(s string, patterns [][2]string) string {
+	for _, p := range patterns {
+		if strings.Contains(s, p[0]) {
+			return p[1]
+		}
+	}
+
+	return ""
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"errors"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/popeskul/houser/pkg/passwordpolicy"
 )
 
 // NewValidator func for create a new validator for model fields.
@@ -27,6 +30,13 @@ func ValidatorErrors(err error) map[string]string {
 	// Define fields map.
 	fields := map[string]string{}
 
+	// Password policy reports all violations of the password field at once.
+	var policyErr *passwordpolicy.PolicyError
+	if errors.As(err, &policyErr) {
+		fields["Password"] = policyErr.Error()
+		return fields
+	}
+
 	// Make error message for each invalid field.
 	for _, err := range err.(validator.ValidationErrors) {
 		fields[err.Field()] = err.Error()
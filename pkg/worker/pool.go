@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Job func to describe one task of the pool.
+// Its context is cancelled, when the job runs longer than the pool timeout.
+type Job func(ctx context.Context)
+
+// Pool struct to describe a fixed number of workers with a bounded queue.
+type Pool struct {
+	jobs    chan Job
+	timeout time.Duration
+
+	mu      sync.RWMutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewPool func for starting workers, which take jobs from a queue of the given size.
+func NewPool(workers, queueSize int, timeout time.Duration) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &Pool{
+		jobs:    make(chan Job, queueSize),
+		timeout: timeout,
+	}
+
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+// Submit method for adding a job to the queue without waiting.
+// It reports false, if the queue is full or the pool is stopped.
+func (p *Pool) Submit(job Job) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return false
+	}
+
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop method for waiting until queued jobs are done, new jobs are rejected.
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// work method for running jobs until the queue is closed.
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for job := range p.jobs {
+		p.run(job)
+	}
+}
+
+// run method for running one job with the pool timeout.
+func (p *Pool) run(job Job) {
+	ctx := context.Background()
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	job(ctx)
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolRunsJobs(t *testing.T) {
+	p := NewPool(2, 10, time.Second)
+
+	var done int32
+	for i := 0; i < 5; i++ {
+		assert.True(t, p.Submit(func(ctx context.Context) { atomic.AddInt32(&done, 1) }))
+	}
+
+	// Stop waits for queued jobs.
+	p.Stop()
+	assert.Equal(t, int32(5), atomic.LoadInt32(&done))
+
+	// Stopped pool rejects new jobs.
+	assert.False(t, p.Submit(func(ctx context.Context) {}))
+}
+
+func TestPoolRejectsJobsWhenQueueIsFull(t *testing.T) {
+	p := NewPool(1, 1, time.Second)
+	defer p.Stop()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	// The only worker is busy with the first job.
+	assert.True(t, p.Submit(func(ctx context.Context) {
+		close(started)
+		<-release
+	}))
+	<-started
+
+	// The second job waits in the queue, the third one does not fit.
+	assert.True(t, p.Submit(func(ctx context.Context) {}))
+	assert.False(t, p.Submit(func(ctx context.Context) {}))
+
+	close(release)
+}
+
+func TestPoolCancelsSlowJobs(t *testing.T) {
+	p := NewPool(1, 1, 10*time.Millisecond)
+
+	errs := make(chan error, 1)
+	assert.True(t, p.Submit(func(ctx context.Context) {
+		<-ctx.Done()
+		errs <- ctx.Err()
+	}))
+
+	p.Stop()
+	assert.Equal(t, context.DeadlineExceeded, <-errs)
+}
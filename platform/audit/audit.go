@@ -0,0 +1,85 @@
+// Package audit keeps trail of actions made by admins on behalf of other users.
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/platform/database"
+)
+
+// Logger interface to describe persistent storage of audit entries.
+type Logger interface {
+	Record(ctx context.Context, entry *models.ImpersonationAuditEntry) error
+}
+
+// DatabaseLogger struct to describe audit log in PostgreSQL.
+type DatabaseLogger struct {
+	db *database.Queries
+}
+
+// NewDatabaseLogger func for create a new audit log on top of the shared connection pool.
+func NewDatabaseLogger(db *database.Queries) *DatabaseLogger {
+	return &DatabaseLogger{db: db}
+}
+
+// Record method for saving audit entry to the database.
+func (l *DatabaseLogger) Record(ctx context.Context, entry *models.ImpersonationAuditEntry) error {
+	if l.db == nil {
+		return database.ErrNotConfigured
+	}
+
+	return l.db.CreateImpersonationAuditEntry(ctx, entry)
+}
+
+// MemoryLogger struct to describe audit log in memory.
+// It is meant for tests.
+type MemoryLogger struct {
+	mu      sync.Mutex
+	entries []models.ImpersonationAuditEntry
+}
+
+// Record method for saving audit entry in memory.
+func (l *MemoryLogger) Record(ctx context.Context, entry *models.ImpersonationAuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, *entry)
+
+	return nil
+}
+
+// Entries method for getting copy of saved entries.
+func (l *MemoryLogger) Entries() []models.ImpersonationAuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]models.ImpersonationAuditEntry(nil), l.entries...)
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultLogger Logger
+)
+
+// Default func returns the audit log shared by the whole app.
+func Default() Logger {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	// The app sets the log with database of its container on start.
+	if defaultLogger == nil {
+		defaultLogger = &DatabaseLogger{}
+	}
+
+	return defaultLogger
+}
+
+// SetDefault func replaces the audit log shared by the whole app.
+func SetDefault(l Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultLogger = l
+}
@@ -0,0 +1,139 @@
+// Package container keeps dependencies, which are created once on start
+// and shared by all requests, e.g. database connection pool.
+package container
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/app/repository"
+	"github.com/popeskul/houser/platform/database"
+)
+
+// localsKey is used to keep container in request locals.
+const localsKey = "container"
+
+// locals struct to keep container in request locals.
+// Fasthttp closes every io.Closer in locals after the request, so the
+// container itself must not be kept there, or the pool is closed.
+type locals struct {
+	c *Container
+}
+
+// Container struct to describe dependencies of the app.
+// Handlers, which need only users and houses, use repositories, so tests
+// can replace them with app/repository/memory.
+type Container struct {
+	DB      *database.Queries
+	Users   repository.UserRepository
+	Houses  repository.HouseRepository
+	Auth    repository.AuthRepository
+	APIKeys repository.APIKeyRepository
+	Tx      repository.Transactor
+}
+
+// New func for create dependencies of the app from .yml file.
+func New() (*Container, error) {
+	// Open the only database connection pool.
+	db, err := database.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithDB(db), nil
+}
+
+// NewWithDB func for create dependencies of the app on top of the given connection pool.
+func NewWithDB(db *database.Queries) *Container {
+	return &Container{
+		DB:      db,
+		Users:   db,
+		Houses:  db,
+		Auth:    db,
+		APIKeys: db,
+		Tx:      db,
+	}
+}
+
+// Inject method for middleware, which gives the container to handlers of every request.
+func (c *Container) Inject() func(*fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		ctx.Locals(localsKey, &locals{c: c})
+
+		return ctx.Next()
+	}
+}
+
+// Close method for release dependencies, it is called on shutdown.
+func (c *Container) Close() error {
+	return c.DB.Close()
+}
+
+// FromContext func returns the container of the request.
+func FromContext(ctx *fiber.Ctx) (*Container, bool) {
+	l, ok := ctx.Locals(localsKey).(*locals)
+	if !ok || l.c == nil {
+		return nil, false
+	}
+
+	return l.c, true
+}
+
+// DB func returns shared database connection pool for the request.
+func DB(ctx *fiber.Ctx) (*database.Queries, error) {
+	c, ok := FromContext(ctx)
+	if !ok || c.DB == nil {
+		return nil, database.ErrNotConfigured
+	}
+
+	return c.DB, nil
+}
+
+// Users func returns users repository for the request.
+func Users(ctx *fiber.Ctx) (repository.UserRepository, error) {
+	c, ok := FromContext(ctx)
+	if !ok || c.Users == nil {
+		return nil, database.ErrNotConfigured
+	}
+
+	return c.Users, nil
+}
+
+// Houses func returns houses repository for the request.
+func Houses(ctx *fiber.Ctx) (repository.HouseRepository, error) {
+	c, ok := FromContext(ctx)
+	if !ok || c.Houses == nil {
+		return nil, database.ErrNotConfigured
+	}
+
+	return c.Houses, nil
+}
+
+// Auth func returns credentials repository for the request.
+func Auth(ctx *fiber.Ctx) (repository.AuthRepository, error) {
+	c, ok := FromContext(ctx)
+	if !ok || c.Auth == nil {
+		return nil, database.ErrNotConfigured
+	}
+
+	return c.Auth, nil
+}
+
+// APIKeys func returns API keys repository for the request.
+func APIKeys(ctx *fiber.Ctx) (repository.APIKeyRepository, error) {
+	c, ok := FromContext(ctx)
+	if !ok || c.APIKeys == nil {
+		return nil, database.ErrNotConfigured
+	}
+
+	return c.APIKeys, nil
+}
+
+// Transactor func returns unit of work for the request, which runs several
+// repository calls in one transaction.
+func Transactor(ctx *fiber.Ctx) (repository.Transactor, error) {
+	c, ok := FromContext(ctx)
+	if !ok || c.Tx == nil {
+		return nil, database.ErrNotConfigured
+	}
+
+	return c.Tx, nil
+}
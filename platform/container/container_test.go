@@ -0,0 +1,110 @@
+package container
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/popeskul/houser/platform/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBWithoutContainer(t *testing.T) {
+	// Define a new Fiber app without dependencies.
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		_, err := DB(c)
+
+		return err
+	})
+
+	// Handlers get error instead of opening a new connection.
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestContainerIsNotClosedAfterRequest(t *testing.T) {
+	// Define a new Fiber app with dependencies, which are not connected.
+	deps := &Container{DB: &database.Queries{}}
+	app := fiber.New()
+	app.Use(deps.Inject())
+	app.Get("/", func(c *fiber.Ctx) error {
+		_, err := DB(c)
+
+		return err
+	})
+
+	// Locals are released after every request, the pool must stay open.
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestConnectionPoolUnderLoad(t *testing.T) {
+	// Define database settings of the test environment with a small pool.
+	configs.SetDefault(&configs.Config{DB: configs.DBConfig{
+		Host:                  "localhost",
+		Port:                  5432,
+		Username:              "postgres",
+		Password:              "123123",
+		DBName:                "houser_db",
+		SSLMode:               "disable",
+		MaxConnections:        5,
+		MaxIdleConnections:    5,
+		MaxConnectionLifetime: 2 * time.Minute,
+	}})
+	defer configs.SetDefault(nil)
+
+	// Open the only connection pool, skip, if PostgreSQL is not running.
+	db, err := database.Open()
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+
+	// Define a new Fiber app, which uses the pool in every request.
+	deps := NewWithDB(db)
+	app := fiber.New()
+	app.Use(deps.Inject())
+	app.Get("/", func(c *fiber.Ctx) error {
+		db, err := DB(c)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.UserQueries.ExecContext(c.UserContext(), `SELECT pg_sleep(0.01)`)
+
+		return err
+	})
+
+	// Send several rounds of concurrent requests.
+	for round := 0; round < 5; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+				if assert.NoError(t, err) {
+					assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Connections count never grows above the pool size.
+		stats := db.Stats()
+		assert.LessOrEqual(t, stats.OpenConnections, 5, fmt.Sprintf("round %d", round))
+	}
+
+	// All connections are closed with the pool on shutdown.
+	assert.NoError(t, deps.Close())
+	assert.Equal(t, 0, db.Stats().OpenConnections)
+}
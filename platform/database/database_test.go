@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/popeskul/houser/app/queries"
+	"github.com/popeskul/houser/app/repository"
+	"github.com/popeskul/houser/app/repository/repositorytest"
+	"github.com/popeskul/houser/pkg/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueriesContract(t *testing.T) {
+	// Define database settings of the test environment.
+	configs.SetDefault(&configs.Config{DB: configs.DBConfig{
+		Host:               "localhost",
+		Port:               5432,
+		Username:           "postgres",
+		Password:           "123123",
+		DBName:             "houser_db",
+		SSLMode:            "disable",
+		TransactionRetries: 3,
+	}})
+	defer configs.SetDefault(nil)
+
+	// Open connection pool, skip, if PostgreSQL is not running.
+	db, err := Open()
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	defer db.Close()
+
+	repositorytest.Run(t, func(t *testing.T) (repository.Repositories, repository.Transactor) {
+		return repository.Repositories{Users: db, Houses: db, Auth: db}, db
+	})
+	t.Run("APIKeyRepository", func(t *testing.T) {
+		repositorytest.TestAPIKeyRepository(t, db)
+	})
+	t.Run("SignInThrottle", func(t *testing.T) {
+		testSignInThrottle(t, db)
+	})
+}
+
+// testSignInThrottle func checks, that failures are counted from scratch after lockout.
+func testSignInThrottle(t *testing.T, db *Queries) {
+	ctx := context.Background()
+	key := "account:" + uuid.New().String()
+	ipKey := "ip:" + uuid.New().String()
+	defer func() { _ = db.ResetSignInThrottle(ctx, key) }()
+	defer func() { _ = db.ResetSignInThrottle(ctx, ipKey) }()
+
+	// Failures are counted within the window.
+	for i := 1; i <= 3; i++ {
+		failures, err := db.RecordSignInFailure(ctx, key, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, i, failures)
+	}
+
+	// Failures keep counting while the account is locked.
+	require.NoError(t, db.BlockSignIn(ctx, key, time.Now().Add(time.Hour), true))
+
+	failures, err := db.RecordSignInFailure(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 4, failures)
+
+	throttle, err := db.GetSignInThrottle(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, throttle.Locked)
+
+	// The first failure after lockout starts from scratch and unlocks the account.
+	require.NoError(t, db.BlockSignIn(ctx, key, time.Now().Add(-time.Second), true))
+
+	failures, err = db.RecordSignInFailure(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures)
+
+	throttle, err = db.GetSignInThrottle(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, throttle.Locked)
+
+	// Parallel attempt with the same key is rejected at once, not after the running one.
+	tx, throttles, err := db.LockSignInThrottles(ctx, key, ipKey)
+	require.NoError(t, err)
+	assert.Len(t, throttles, 2)
+
+	_, _, err = db.LockSignInThrottles(ctx, key)
+	assert.ErrorIs(t, err, queries.ErrSignInThrottleBusy)
+
+	// The failure is counted before the throttle is unlocked.
+	failures, err = tx.RecordSignInFailure(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, failures)
+	require.NoError(t, tx.Commit())
+
+	tx, throttles, err = db.LockSignInThrottles(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, throttles[0].Failures)
+	require.NoError(t, tx.Rollback())
+}
+
+func TestRetryable(t *testing.T) {
+	// Only transactions aborted because of concurrent ones are run again.
+	assert.True(t, retryable(&pq.Error{Code: "40001"}))
+	assert.True(t, retryable(fmt.Errorf("update house: %w", &pq.Error{Code: "40P01"})))
+	assert.False(t, retryable(&pq.Error{Code: "23505"}))
+	assert.False(t, retryable(sql.ErrNoRows))
+	assert.False(t, retryable(nil))
+}
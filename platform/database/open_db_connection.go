@@ -1,26 +1,81 @@
 package database
 
-import "github.com/popeskul/houser/app/queries"
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/popeskul/houser/app/queries"
+	"github.com/popeskul/houser/platform/migrations"
+)
+
+// ErrNotConfigured is returned, if database pool was not opened for the app.
+var ErrNotConfigured = errors.New("database is not configured")
 
 // Queries struct for collect all app queries.
+// All queries share one connection pool.
 type Queries struct {
-	*queries.UserQueries  // load queries from User model
-	*queries.HouseQueries // load queries from House model
-	*queries.AuthQueries  // load queries from Login model
+	db *sqlx.DB // connection pool, see Close and InTx
+
+	*queries.UserQueries         // load queries from User model
+	*queries.HouseQueries        // load queries from House model
+	*queries.AuthQueries         // load queries from Login model
+	*queries.TokenQueries        // load queries from RefreshToken model
+	*queries.RevocationQueries   // load queries for revoked tokens
+	*queries.VerificationQueries // load queries from EmailVerificationToken model
+	*queries.PasswordQueries     // load queries from PasswordResetToken model
+	*queries.MFAQueries          // load queries from UserMFA model
+	*queries.APIKeyQueries       // load queries from APIKey model
+	*queries.SignInQueries       // load queries from SignInAttempt model
+	*queries.IdentityQueries     // load queries from UserIdentity model
+	*queries.SessionQueries      // load queries from Session model
+	*queries.AuditQueries        // load queries from ImpersonationAuditEntry model
 }
 
-// OpenDBConnection func for opening database connection.
-func OpenDBConnection() (*Queries, error) {
-	// Define a new PostgreSQL connection.
+// Open func for opening database connection pool.
+// It is meant to be called once on start, the pool is shared by all requests.
+func Open() (*Queries, error) {
+	// Define a new PostgreSQL connection pool.
 	db, err := PostgreSQLConnection()
 	if err != nil {
 		return nil, err
 	}
 
 	return &Queries{
+		db: db,
+
 		// Set queries from models:
-		UserQueries:  &queries.UserQueries{DB: db},  // from User model
-		HouseQueries: &queries.HouseQueries{DB: db}, // from House model
-		AuthQueries:  &queries.AuthQueries{DB: db},  // from House model
+		UserQueries:         &queries.UserQueries{Executor: db},   // from User model
+		HouseQueries:        &queries.HouseQueries{Executor: db},  // from House model
+		AuthQueries:         &queries.AuthQueries{Executor: db},   // from House model
+		TokenQueries:        &queries.TokenQueries{DB: db},        // from RefreshToken model
+		RevocationQueries:   &queries.RevocationQueries{DB: db},   // for revoked tokens
+		VerificationQueries: &queries.VerificationQueries{DB: db}, // from EmailVerificationToken model
+		PasswordQueries:     &queries.PasswordQueries{DB: db},     // from PasswordResetToken model
+		MFAQueries:          &queries.MFAQueries{DB: db},          // from UserMFA model
+		APIKeyQueries:       &queries.APIKeyQueries{DB: db},       // from APIKey model
+		SignInQueries:       &queries.SignInQueries{Executor: db}, // from SignInAttempt model
+		IdentityQueries:     &queries.IdentityQueries{DB: db},     // from UserIdentity model
+		SessionQueries:      &queries.SessionQueries{DB: db},      // from Session model
+		AuditQueries:        &queries.AuditQueries{DB: db},        // from ImpersonationAuditEntry model
 	}, nil
 }
+
+// Close method for closing connection pool, it must be called once on shutdown.
+func (q *Queries) Close() error {
+	return q.db.Close()
+}
+
+// Stats method returns statistics of connection pool.
+func (q *Queries) Stats() sql.DBStats {
+	return q.db.Stats()
+}
+
+// Migrator method returns runner of schema migrations embedded into the binary.
+func (q *Queries) Migrator() (*migrations.Migrator, error) {
+	if q.db == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return migrations.New(q.db.DB)
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/popeskul/houser/app/models"
+	"github.com/popeskul/houser/app/queries"
+	"github.com/popeskul/houser/app/repository"
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// InTx method runs repository calls of fn in one transaction.
+// The transaction is rolled back, if fn returns error or panics, and it is
+// run again from scratch, if PostgreSQL aborts it because of concurrent one.
+func (q *Queries) InTx(ctx context.Context, fn func(tx repository.Repositories) error) error {
+	if q.db == nil {
+		return ErrNotConfigured
+	}
+
+	// Set retries count of aborted transactions from config.
+	retriesCount := configs.Default().DB.TransactionRetries
+
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, fn)
+		if attempt < retriesCount && retryable(err) && ctx.Err() == nil {
+			continue
+		}
+
+		return err
+	}
+}
+
+// runTx method runs fn in a new transaction once.
+func (q *Queries) runTx(ctx context.Context, fn func(tx repository.Repositories) error) (err error) {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Rollback on panic and pass the panic on.
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+
+		// Rollback on error of fn or commit.
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := fn(txRepositories(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SignInThrottleTx struct to describe transaction, which keeps throttles of one sign in attempt locked.
+type SignInThrottleTx struct {
+	*queries.SignInQueries // queries run in the transaction
+
+	tx *sqlx.Tx
+}
+
+// LockSignInThrottles method for starting transaction, which locks throttles of the given keys,
+// e.g. of the account and the client IP. Attempts with the same keys are not waited for,
+// queries.ErrSignInThrottleBusy is returned for them. The caller must Commit or Rollback it.
+func (q *Queries) LockSignInThrottles(ctx context.Context, keys ...string) (*SignInThrottleTx, []models.SignInThrottle, error) {
+	if q.db == nil {
+		return nil, nil, ErrNotConfigured
+	}
+
+	// Keys are locked in the same order by all attempts.
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	// Create missing throttles before the transaction, rows must exist to be locked.
+	for _, key := range sorted {
+		if err := q.CreateSignInThrottle(ctx, key); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := &SignInThrottleTx{SignInQueries: &queries.SignInQueries{Executor: tx}, tx: tx}
+
+	throttles := make([]models.SignInThrottle, 0, len(sorted))
+	for _, key := range sorted {
+		throttle, err := t.LockSignInThrottle(ctx, key)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, nil, err
+		}
+		throttles = append(throttles, throttle)
+	}
+
+	return t, throttles, nil
+}
+
+// Commit method for saving changes of the transaction and unlocking throttles.
+func (t *SignInThrottleTx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback method for dropping changes of the transaction and unlocking throttles.
+func (t *SignInThrottleTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// txRepositories func returns repositories, which run queries in the given transaction.
+func txRepositories(tx *sqlx.Tx) repository.Repositories {
+	return repository.Repositories{
+		Users:  &queries.UserQueries{Executor: tx},
+		Houses: &queries.HouseQueries{Executor: tx},
+		Auth:   &queries.AuthQueries{Executor: tx},
+	}
+}
+
+// retryable func reports whether transaction failed because of concurrent one
+// and may succeed, if it is run again.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	switch pqErr.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,15 @@
+-- Create refresh_tokens table
+CREATE TABLE refresh_tokens (
+    id          UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    user_id     UUID not null,
+    family_id   UUID not null,
+    token_hash  varchar(64) not null unique,
+    replaced_by UUID,
+    expires_at  timestamp with time zone not null,
+    revoked_at  timestamp with time zone,
+    created_at  timestamp with time zone not null default now()
+);
+
+ALTER TABLE refresh_tokens ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON refresh_tokens ("family_id");
+CREATE INDEX ON refresh_tokens ("user_id");
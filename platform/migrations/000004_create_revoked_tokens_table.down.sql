@@ -0,0 +1,3 @@
+-- Delete tables
+DROP TABLE IF EXISTS revoked_user_tokens;
+DROP TABLE IF EXISTS revoked_tokens;
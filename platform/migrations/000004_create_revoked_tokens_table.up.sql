@@ -0,0 +1,18 @@
+-- Create revoked_tokens table
+CREATE TABLE revoked_tokens (
+    jti        UUID primary key not null unique,
+    user_id    UUID not null,
+    expires_at timestamp with time zone not null,
+    revoked_at timestamp with time zone not null default now()
+);
+
+CREATE INDEX ON revoked_tokens ("expires_at");
+
+-- Create revoked_user_tokens table, all tokens of the user
+-- issued before revoked_before are treated as revoked
+CREATE TABLE revoked_user_tokens (
+    user_id        UUID primary key not null unique,
+    revoked_before timestamp with time zone not null
+);
+
+ALTER TABLE revoked_user_tokens ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
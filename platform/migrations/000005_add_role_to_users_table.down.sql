@@ -0,0 +1,2 @@
+-- Delete role column
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,3 @@
+-- Add role column to users table, existing users become owners
+ALTER TABLE users ADD COLUMN role varchar(16) not null default 'owner';
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'agent', 'owner', 'viewer'));
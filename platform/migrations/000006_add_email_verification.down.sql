@@ -0,0 +1,5 @@
+-- Delete tables
+DROP TABLE IF EXISTS email_verification_tokens;
+
+-- Delete verified_at column
+ALTER TABLE users DROP COLUMN IF EXISTS verified_at;
@@ -0,0 +1,17 @@
+-- Add verified_at column to users table, accounts created before
+-- email verification are treated as verified
+ALTER TABLE users ADD COLUMN verified_at timestamp with time zone;
+UPDATE users SET verified_at = created_at;
+
+-- Create email_verification_tokens table
+CREATE TABLE email_verification_tokens (
+    jti        UUID primary key not null unique,
+    user_id    UUID not null,
+    email      varchar(255) not null,
+    expires_at timestamp with time zone not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+ALTER TABLE email_verification_tokens ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON email_verification_tokens ("user_id", "created_at");
@@ -0,0 +1,12 @@
+-- Create password_reset_tokens table
+CREATE TABLE password_reset_tokens (
+    id         UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    user_id    UUID not null,
+    token_hash varchar(64) not null unique,
+    expires_at timestamp with time zone not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+ALTER TABLE password_reset_tokens ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON password_reset_tokens ("user_id", "created_at");
@@ -0,0 +1,22 @@
+-- Create user_mfa table
+CREATE TABLE user_mfa (
+    user_id        UUID primary key not null,
+    secret         varchar(64) not null,
+    enabled_at     timestamp with time zone,
+    last_used_step bigint not null default 0,
+    created_at     timestamp with time zone not null default now()
+);
+
+ALTER TABLE user_mfa ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Create mfa_recovery_codes table
+CREATE TABLE mfa_recovery_codes (
+    id         UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    user_id    UUID not null,
+    code_hash  varchar(64) not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+ALTER TABLE mfa_recovery_codes ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON mfa_recovery_codes ("user_id", "code_hash");
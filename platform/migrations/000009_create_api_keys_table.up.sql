@@ -0,0 +1,16 @@
+-- Create api_keys table
+CREATE TABLE api_keys (
+    id           UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    user_id      UUID not null,
+    name         varchar(255) not null,
+    prefix       varchar(16) not null,
+    key_hash     varchar(64) not null unique,
+    scopes       text[] not null,
+    expires_at   timestamp with time zone,
+    last_used_at timestamp with time zone,
+    revoked_at   timestamp with time zone,
+    created_at   timestamp with time zone not null default now()
+);
+
+ALTER TABLE api_keys ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON api_keys ("user_id");
@@ -0,0 +1,3 @@
+-- Delete tables
+DROP TABLE IF EXISTS sign_in_throttles;
+DROP TABLE IF EXISTS sign_in_attempts;
@@ -0,0 +1,22 @@
+-- Create sign_in_attempts table
+CREATE TABLE sign_in_attempts (
+    id         UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    user_id    UUID,
+    email      varchar(255) not null,
+    ip         varchar(64) not null,
+    user_agent text not null,
+    outcome    varchar(32) not null,
+    created_at timestamp with time zone not null default now()
+);
+
+ALTER TABLE sign_in_attempts ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON sign_in_attempts ("user_id", "created_at");
+
+-- Create sign_in_throttles table, keys are accounts and client IPs
+CREATE TABLE sign_in_throttles (
+    key           varchar(320) primary key not null,
+    failures      integer not null default 0,
+    blocked_until timestamp with time zone,
+    locked        boolean not null default false,
+    updated_at    timestamp with time zone not null default now()
+);
@@ -0,0 +1,13 @@
+-- Create user_identities table
+CREATE TABLE user_identities (
+    id         UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    user_id    UUID not null,
+    provider   varchar(64) not null,
+    subject    varchar(255) not null,
+    email      varchar(255) not null,
+    created_at timestamp with time zone not null default now(),
+    UNIQUE (provider, subject)
+);
+
+ALTER TABLE user_identities ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON user_identities ("user_id");
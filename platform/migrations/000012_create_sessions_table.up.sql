@@ -0,0 +1,14 @@
+-- Create sessions table, every session is a family of refresh tokens
+CREATE TABLE sessions (
+    id           UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    user_id      UUID not null,
+    device       varchar(255) not null,
+    ip           varchar(64) not null,
+    user_agent   text not null,
+    created_at   timestamp with time zone not null default now(),
+    last_seen_at timestamp with time zone not null default now(),
+    revoked_at   timestamp with time zone
+);
+
+ALTER TABLE sessions ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+CREATE INDEX ON sessions ("user_id");
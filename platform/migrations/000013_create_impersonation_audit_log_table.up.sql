@@ -0,0 +1,15 @@
+-- Create impersonation_audit_log table
+CREATE TABLE impersonation_audit_log (
+    id         UUID DEFAULT uuid_generate_v4() primary key not null unique,
+    actor_id   UUID not null,
+    user_id    UUID not null,
+    token_id   UUID not null,
+    action     varchar(32) not null,
+    method     varchar(16) not null,
+    path       text not null,
+    ip         varchar(64) not null,
+    created_at timestamp with time zone not null default now()
+);
+
+CREATE INDEX ON impersonation_audit_log ("actor_id", "created_at");
+CREATE INDEX ON impersonation_audit_log ("user_id", "created_at");
@@ -0,0 +1,2 @@
+-- Remove legacy plaintext marker
+UPDATE users SET password = substr(password, 8) WHERE left(password, 7) = '$plain$';
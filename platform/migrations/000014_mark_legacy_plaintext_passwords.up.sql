@@ -0,0 +1,6 @@
+-- Mark passwords, which are not argon2id or bcrypt hashes, as legacy plaintext.
+-- They are accepted only with password.legacy_plaintext and hashed on next sign in.
+UPDATE users SET password = '$plain$' || password
+WHERE password !~ '^\$argon2id\$v=[0-9]+\$m=[0-9]+,t=[0-9]+,p=[0-9]+\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+$'
+  AND password !~ '^\$2[aby]\$[0-9]{2}\$[./A-Za-z0-9]{53}$'
+  AND left(password, 7) <> '$plain$';
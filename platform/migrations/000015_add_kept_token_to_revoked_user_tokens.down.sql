@@ -0,0 +1,2 @@
+-- Delete kept token column
+ALTER TABLE revoked_user_tokens DROP COLUMN IF EXISTS kept_token_id;
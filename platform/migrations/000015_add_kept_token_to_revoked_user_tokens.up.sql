@@ -0,0 +1,2 @@
+-- Add token, which stays valid, when all other tokens of the user are revoked
+ALTER TABLE revoked_user_tokens ADD COLUMN kept_token_id UUID;
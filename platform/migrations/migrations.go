@@ -0,0 +1,87 @@
+// Package migrations keeps SQL migrations of the database schema inside the
+// binary and applies them, so no external migrate CLI is needed.
+//
+// Files are named VERSION_NAME.up.sql and VERSION_NAME.down.sql, the applied
+// version is kept in schema_migrations table like golang-migrate does, so
+// databases migrated with the CLI before keep working.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// fileName describes name of migration file, e.g. 000001_create_users_table.up.sql.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration struct to describe one change of the schema.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// load func reads migrations from the given files sorted by version.
+// Every migration must have both up and down files.
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, name := range names {
+		match := fileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("migrations: invalid file name %q", name)
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migrations: invalid version of %q", name)
+		}
+
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations: version %d has different names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrations: version %d needs both up and down files", m.Version)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
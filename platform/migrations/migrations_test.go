@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	require.NoError(t, err)
+
+	// Every file is embedded and versions go one by one.
+	names, err := files.ReadDir(".")
+	require.NoError(t, err)
+	assert.Len(t, migrations, len(names)/2)
+
+	for i, migration := range migrations {
+		assert.Equal(t, uint(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		description string
+		files       fstest.MapFS
+	}{
+		{
+			description: "invalid name",
+			files:       fstest.MapFS{"create_users.up.sql": {Data: []byte("SELECT 1")}},
+		},
+		{
+			description: "missing down file",
+			files:       fstest.MapFS{"000001_create_users.up.sql": {Data: []byte("SELECT 1")}},
+		},
+		{
+			description: "different names of one version",
+			files: fstest.MapFS{
+				"000001_create_users.up.sql":    {Data: []byte("SELECT 1")},
+				"000001_create_houses.down.sql": {Data: []byte("SELECT 1")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := load(test.files)
+		assert.Errorf(t, err, test.description)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	// Connect to database of the test environment, skip, if PostgreSQL is not running.
+	db, err := sqlx.Connect("postgres", "host=localhost port=5432 user=postgres password=123123 dbname=houser_db sslmode=disable")
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	defer db.Close()
+
+	// Use own tables, so the schema of the app is not touched.
+	suffix := strings.ReplaceAll(uuid.New().String(), "-", "")
+	table := "schema_migrations_" + suffix
+	files := fstest.MapFS{}
+	for i, name := range []string{"first", "second", "third"} {
+		files[fmt.Sprintf("%06d_create_%s.up.sql", i+1, name)] = &fstest.MapFile{Data: []byte(fmt.Sprintf("CREATE TABLE %s_%s (id int)", name, suffix))}
+		files[fmt.Sprintf("%06d_create_%s.down.sql", i+1, name)] = &fstest.MapFile{Data: []byte(fmt.Sprintf("DROP TABLE %s_%s", name, suffix))}
+	}
+	defer db.Exec(`DROP TABLE IF EXISTS ` + table)
+
+	ctx := context.Background()
+	m, err := newMigrator(db.DB, files, table)
+	require.NoError(t, err)
+
+	// Replicas started at once apply migrations only once.
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, m.Up(ctx))
+		}()
+	}
+	wg.Wait()
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), status.Version)
+	assert.False(t, status.Dirty)
+	assert.Len(t, status.Migrations, 3)
+
+	// Down reverts one migration, To moves to any version.
+	require.NoError(t, m.Down(ctx))
+	assertVersion(t, m, 2)
+
+	require.NoError(t, m.To(ctx, 3))
+	assertVersion(t, m, 3)
+
+	require.NoError(t, m.To(ctx, 1))
+	assertVersion(t, m, 1)
+
+	assert.Error(t, m.To(ctx, 42))
+
+	require.NoError(t, m.To(ctx, 0))
+	assertVersion(t, m, 0)
+
+	// Dirty database is not touched.
+	_, err = db.Exec(`INSERT INTO ` + table + ` (version, dirty) VALUES (1, true)`)
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Up(ctx), ErrDirty)
+}
+
+// assertVersion func checks applied version of the schema.
+func assertVersion(t *testing.T, m *Migrator, version uint) {
+	t.Helper()
+
+	status, err := m.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, version, status.Version)
+}
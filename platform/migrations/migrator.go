@@ -0,0 +1,248 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// lockID is the key of PostgreSQL advisory lock, which is held while
+// migrations run, so replicas started at once do not migrate together.
+const lockID int64 = 7351920416
+
+// ErrDirty is returned, if a migration failed in the middle before,
+// e.g. it was applied by migrate CLI, and the schema must be fixed by hand.
+var ErrDirty = errors.New("migrations: database is dirty, fix the schema and its version in schema_migrations by hand")
+
+// Status struct to describe applied version of the schema.
+type Status struct {
+	Version    uint // 0, if nothing is applied
+	Dirty      bool
+	Migrations []MigrationStatus
+}
+
+// MigrationStatus struct to describe one known migration.
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Migrator struct to describe runner of migrations against a database.
+type Migrator struct {
+	db         *sql.DB
+	table      string
+	migrations []Migration
+}
+
+// New func for create a new runner of migrations embedded into the binary.
+func New(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, files, "schema_migrations")
+}
+
+// newMigrator func for create a new runner of the given migrations and version table.
+func newMigrator(db *sql.DB, fsys fs.FS, table string) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, table: table, migrations: migrations}, nil
+}
+
+// Up method for applying all new migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.latest())
+}
+
+// Down method for reverting the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.current(ctx, conn)
+		if err != nil || current == 0 {
+			return err
+		}
+
+		return m.migrate(ctx, conn, current, m.previous(current))
+	})
+}
+
+// To method for applying or reverting migrations until the schema has the given version.
+// Version 0 reverts all migrations.
+func (m *Migrator) To(ctx context.Context, version uint) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("migrations: unknown version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		return m.migrate(ctx, conn, current, version)
+	})
+}
+
+// Status method for getting applied version and known migrations.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	status := Status{}
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		status.Version, status.Dirty = version, dirty
+		for _, migration := range m.migrations {
+			status.Migrations = append(status.Migrations, MigrationStatus{
+				Migration: migration,
+				Applied:   migration.Version <= version,
+			})
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+// migrate method for moving the schema from current version to target one step by step.
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, current, target uint) error {
+	if current != 0 && m.index(current) < 0 {
+		return fmt.Errorf("migrations: database has version %d, which is unknown to the app", current)
+	}
+
+	// Apply new migrations in order.
+	for _, migration := range m.migrations {
+		if migration.Version > current && migration.Version <= target {
+			if err := m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migrations: up %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+	}
+
+	// Revert applied migrations in reverse order.
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= current && migration.Version > target {
+			if err := m.apply(ctx, conn, migration.Down, m.previous(migration.Version)); err != nil {
+				return fmt.Errorf("migrations: down %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// apply method for running one migration and saving the new version in one transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, query string, version uint) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+m.table); err != nil {
+		return err
+	}
+
+	if version != 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO `+m.table+` (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// withLock method for running fn on one connection, which holds the advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Wait, until other replica is done with migrations.
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	// Create version table on the first run, the same as migrate CLI does.
+	query := `CREATE TABLE IF NOT EXISTS ` + m.table + ` (version bigint not null primary key, dirty boolean not null)`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// current method for getting applied version, which is not dirty.
+func (m *Migrator) current(ctx context.Context, conn *sql.Conn) (uint, error) {
+	version, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, ErrDirty
+	}
+
+	return version, nil
+}
+
+// version method for getting applied version from version table.
+func (m *Migrator) version(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM `+m.table+` LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return uint(version), dirty, nil
+}
+
+// latest method returns version of the newest migration.
+func (m *Migrator) latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// previous method returns version, which is before the given one, or 0.
+func (m *Migrator) previous(version uint) uint {
+	i := m.index(version)
+	if i <= 0 {
+		return 0
+	}
+
+	return m.migrations[i-1].Version
+}
+
+// index method returns position of migration with the given version, or -1.
+func (m *Migrator) index(version uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
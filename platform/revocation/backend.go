@@ -0,0 +1,101 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/platform/database"
+)
+
+// DatabaseBackend struct to describe revoked tokens backend in PostgreSQL.
+type DatabaseBackend struct {
+	db *database.Queries
+}
+
+// NewDatabaseBackend func for create a new backend on top of the shared connection pool.
+func NewDatabaseBackend(db *database.Queries) *DatabaseBackend {
+	return &DatabaseBackend{db: db}
+}
+
+// RevokeToken method for saving revoked token to the database.
+func (b *DatabaseBackend) RevokeToken(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error {
+	if b.db == nil {
+		return database.ErrNotConfigured
+	}
+
+	return b.db.RevokeToken(ctx, jti, userID, expiresAt)
+}
+
+// RevokeUserTokens method for saving revocation of all user tokens to the database.
+func (b *DatabaseBackend) RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, keptID uuid.UUID) error {
+	if b.db == nil {
+		return database.ErrNotConfigured
+	}
+
+	return b.db.RevokeUserTokens(ctx, userID, before, keptID)
+}
+
+// GetTokenRevocation method for loading token revocation from the database.
+func (b *DatabaseBackend) GetTokenRevocation(ctx context.Context, jti, userID uuid.UUID) (bool, time.Time, uuid.UUID, error) {
+	if b.db == nil {
+		return false, time.Time{}, uuid.Nil, database.ErrNotConfigured
+	}
+
+	return b.db.GetTokenRevocation(ctx, jti, userID)
+}
+
+// MemoryBackend struct to describe revoked tokens backend in memory.
+// It is meant for tests and single instance setups.
+type MemoryBackend struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]time.Time
+	users  map[uuid.UUID]memoryUser
+}
+
+type memoryUser struct {
+	revokedBefore time.Time
+	keptID        uuid.UUID
+}
+
+// NewMemoryBackend func for create a new empty memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		tokens: map[uuid.UUID]time.Time{},
+		users:  map[uuid.UUID]memoryUser{},
+	}
+}
+
+// RevokeToken method for saving revoked token in memory.
+func (b *MemoryBackend) RevokeToken(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens[jti] = expiresAt
+
+	return nil
+}
+
+// RevokeUserTokens method for saving revocation of all user tokens in memory.
+func (b *MemoryBackend) RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, keptID uuid.UUID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !before.Before(b.users[userID].revokedBefore) {
+		b.users[userID] = memoryUser{revokedBefore: before, keptID: keptID}
+	}
+
+	return nil
+}
+
+// GetTokenRevocation method for loading token revocation from memory.
+func (b *MemoryBackend) GetTokenRevocation(ctx context.Context, jti, userID uuid.UUID) (bool, time.Time, uuid.UUID, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, revoked := b.tokens[jti]
+	user := b.users[userID]
+
+	return revoked, user.revokedBefore, user.keptID, nil
+}
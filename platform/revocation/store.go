@@ -0,0 +1,199 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/popeskul/houser/pkg/configs"
+)
+
+// Backend interface to describe persistent storage of revoked tokens.
+type Backend interface {
+	RevokeToken(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID, before time.Time, keptID uuid.UUID) error
+	GetTokenRevocation(ctx context.Context, jti, userID uuid.UUID) (bool, time.Time, uuid.UUID, error)
+}
+
+// Store struct to describe revoked tokens store with an in-process cache.
+// Revocations made through the store are visible at once, revocations made
+// by other app instances are picked up when cached entries become stale.
+type Store struct {
+	backend Backend
+	ttl     time.Duration
+
+	mu        sync.RWMutex
+	tokens    map[uuid.UUID]cachedToken
+	users     map[uuid.UUID]cachedUser
+	lastPurge time.Time
+}
+
+type cachedToken struct {
+	revoked   bool
+	checkedAt time.Time
+}
+
+type cachedUser struct {
+	revokedBefore time.Time
+	keptID        uuid.UUID // token, which is not revoked with the others
+	checkedAt     time.Time
+}
+
+// revokes method reports whether revocation of all tokens of the user covers the token.
+func (u cachedUser) revokes(jti uuid.UUID, issuedAt time.Time) bool {
+	return !issuedAt.After(u.revokedBefore) && jti != u.keptID
+}
+
+// NewStore func for create a new store with given backend and cache TTL.
+func NewStore(backend Backend, ttl time.Duration) *Store {
+	return &Store{
+		backend:   backend,
+		ttl:       ttl,
+		tokens:    map[uuid.UUID]cachedToken{},
+		users:     map[uuid.UUID]cachedUser{},
+		lastPurge: time.Now(),
+	}
+}
+
+// IsRevoked method for checking, if token with given ID, user and issue time is revoked.
+// Tokens issued at the time of RevokeAll or earlier are revoked, except the kept one.
+func (s *Store) IsRevoked(ctx context.Context, jti, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	revoked, user, err := s.lookup(ctx, jti, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked || user.revokes(jti, issuedAt), nil
+}
+
+// IsSessionRevoked method for checking, if session with given ID was terminated.
+// Revocation of all tokens of the user is checked with the token itself, see IsRevoked.
+func (s *Store) IsSessionRevoked(ctx context.Context, sessionID, userID uuid.UUID) (bool, error) {
+	revoked, _, err := s.lookup(ctx, sessionID, userID)
+
+	return revoked, err
+}
+
+// lookup method for getting revocation of token or session by given ID and revocation
+// of all tokens of the user. Cached entries are used, while they are fresh.
+func (s *Store) lookup(ctx context.Context, id, userID uuid.UUID) (bool, cachedUser, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	token, tokenCached := s.tokens[id]
+	user, userCached := s.users[userID]
+	s.mu.RUnlock()
+
+	// Revocations of tokens are never undone, so a cached one is always valid.
+	if tokenCached && token.revoked {
+		return true, user, nil
+	}
+
+	if tokenCached && userCached && s.fresh(token.checkedAt, now) && s.fresh(user.checkedAt, now) {
+		return false, user, nil
+	}
+
+	revoked, revokedBefore, keptID, err := s.backend.GetTokenRevocation(ctx, id, userID)
+	if err != nil {
+		return false, cachedUser{}, err
+	}
+
+	user = cachedUser{revokedBefore: revokedBefore, keptID: keptID, checkedAt: now}
+
+	s.mu.Lock()
+	s.purge(now)
+	s.tokens[id] = cachedToken{revoked: revoked, checkedAt: now}
+	s.users[userID] = user
+	s.mu.Unlock()
+
+	return revoked, user, nil
+}
+
+// Revoke method for revoking one token by given token ID.
+func (s *Store) Revoke(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error {
+	if err := s.backend.RevokeToken(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens[jti] = cachedToken{revoked: true, checkedAt: time.Now()}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// RevokeAll method for revoking all tokens of user issued up to the current millisecond.
+// Issue time of tokens is kept in milliseconds, so tokens issued in the same second
+// are revoked, while tokens issued later, e.g. on sign in with the new password, stay valid.
+func (s *Store) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	return s.RevokeAllExcept(ctx, userID, uuid.Nil)
+}
+
+// RevokeAllExcept method for revoking all tokens of user like RevokeAll, but the token
+// with given ID stays valid, e.g. the one of the request, which changes the password.
+func (s *Store) RevokeAllExcept(ctx context.Context, userID, keptID uuid.UUID) error {
+	now := time.Now()
+	revokedBefore := now.Truncate(time.Millisecond)
+
+	if err := s.backend.RevokeUserTokens(ctx, userID, revokedBefore, keptID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.users[userID] = cachedUser{revokedBefore: revokedBefore, keptID: keptID, checkedAt: now}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Store) fresh(checkedAt, now time.Time) bool {
+	return now.Sub(checkedAt) < s.ttl
+}
+
+// purge drops stale entries, it must be called with the write lock held.
+func (s *Store) purge(now time.Time) {
+	if s.fresh(s.lastPurge, now) {
+		return
+	}
+
+	for jti, token := range s.tokens {
+		if !s.fresh(token.checkedAt, now) {
+			delete(s.tokens, jti)
+		}
+	}
+
+	for userID, user := range s.users {
+		if !s.fresh(user.checkedAt, now) {
+			delete(s.users, userID)
+		}
+	}
+
+	s.lastPurge = now
+}
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore *Store
+)
+
+// Default func returns the store shared by the whole app.
+func Default() *Store {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	// The app sets the store with database of its container on start.
+	if defaultStore == nil {
+		defaultStore = NewStore(&DatabaseBackend{}, configs.Default().JWT.RevocationCacheTTL)
+	}
+
+	return defaultStore
+}
+
+// SetDefault func replaces the store shared by the whole app.
+func SetDefault(s *Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultStore = s
+}
@@ -0,0 +1,106 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreRevokeAll(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	store := NewStore(backend, time.Minute)
+	userID := uuid.New()
+
+	// Issue time of tokens is kept in milliseconds, see utils.GenerateNewAccessToken.
+	issuedBefore := time.Now().Truncate(time.Millisecond)
+
+	require.NoError(t, store.RevokeAll(ctx, userID))
+
+	// Token issued in the same second as the revocation is revoked.
+	revoked, err := store.IsRevoked(ctx, uuid.New(), userID, issuedBefore)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, uuid.New(), userID, issuedBefore.Add(-time.Second))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Token issued right after the revocation is valid.
+	time.Sleep(time.Millisecond)
+	issuedAfter := time.Now().Truncate(time.Millisecond)
+
+	revoked, err = store.IsRevoked(ctx, uuid.New(), userID, issuedAfter)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// The same is true for instances, which read the revocation from backend.
+	other := NewStore(backend, time.Minute)
+
+	revoked, err = other.IsRevoked(ctx, uuid.New(), userID, issuedBefore)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = other.IsRevoked(ctx, uuid.New(), userID, issuedAfter)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// Tokens of other users are valid.
+	revoked, err = store.IsRevoked(ctx, uuid.New(), uuid.New(), issuedBefore)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestStoreRevokeAllExcept(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	store := NewStore(backend, time.Minute)
+	userID, keptID, sessionID := uuid.New(), uuid.New(), uuid.New()
+	issuedAt := time.Now().Truncate(time.Millisecond)
+
+	require.NoError(t, store.RevokeAllExcept(ctx, userID, keptID))
+
+	// Only the kept token stays valid, on this instance and on others.
+	for _, s := range []*Store{store, NewStore(backend, time.Minute)} {
+		revoked, err := s.IsRevoked(ctx, keptID, userID, issuedAt)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		revoked, err = s.IsRevoked(ctx, uuid.New(), userID, issuedAt)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		// Session of the kept token is not terminated.
+		revoked, err = s.IsSessionRevoked(ctx, sessionID, userID)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	}
+
+	// The next revocation of all tokens revokes the kept one too.
+	time.Sleep(time.Millisecond)
+	require.NoError(t, store.RevokeAll(ctx, userID))
+
+	revoked, err := store.IsRevoked(ctx, keptID, userID, issuedAt)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestStoreRevoke(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(NewMemoryBackend(), time.Minute)
+	jti, userID := uuid.New(), uuid.New()
+
+	require.NoError(t, store.Revoke(ctx, jti, userID, time.Now().Add(time.Hour)))
+
+	revoked, err := store.IsRevoked(ctx, jti, userID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, uuid.New(), userID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}